@@ -0,0 +1,12 @@
+package cleanup
+
+// Cleanup is a data structure to hold cleanup job specific command line
+// configuration flags.
+type Cleanup struct {
+	Args             string
+	Command          string
+	Image            string
+	ImagePullPolicy  string
+	ImagePullSecrets string
+	Namespace        string
+}
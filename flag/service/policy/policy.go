@@ -0,0 +1,7 @@
+package policy
+
+// Policy is a data structure to hold cleanup policy specific command line
+// configuration flags.
+type Policy struct {
+	File string
+}
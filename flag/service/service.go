@@ -2,8 +2,15 @@ package service
 
 import (
 	"github.com/giantswarm/operatorkit/flag/service/kubernetes"
+
+	"github.com/giantswarm/pv-cleaner-operator/flag/service/cleanup"
+	"github.com/giantswarm/pv-cleaner-operator/flag/service/policy"
+	"github.com/giantswarm/pv-cleaner-operator/flag/service/sweeper"
 )
 
 type Service struct {
+	Cleanup    cleanup.Cleanup
 	Kubernetes kubernetes.Kubernetes
+	Policy     policy.Policy
+	Sweeper    sweeper.Sweeper
 }
@@ -0,0 +1,8 @@
+package sweeper
+
+// Sweeper is a data structure to hold orphaned cleanup object sweeper specific
+// command line configuration flags.
+type Sweeper struct {
+	Interval string
+	MinAge   string
+}
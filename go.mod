@@ -3,14 +3,21 @@ module github.com/giantswarm/pv-cleaner-operator
 go 1.14
 
 require (
+	github.com/giantswarm/backoff v0.2.0
 	github.com/giantswarm/k8sclient v0.2.0
 	github.com/giantswarm/microendpoint v0.2.0
 	github.com/giantswarm/microerror v0.2.0
 	github.com/giantswarm/microkit v0.2.0
 	github.com/giantswarm/micrologger v0.3.1
 	github.com/giantswarm/operatorkit v0.2.0
+	github.com/go-kit/kit v0.10.0
+	github.com/gorilla/mux v1.7.3
+	github.com/prometheus/client_golang v1.3.0
 	github.com/spf13/viper v1.6.2
 	k8s.io/api v0.16.6
+	k8s.io/apiextensions-apiserver v0.16.6
 	k8s.io/apimachinery v0.16.6
 	k8s.io/client-go v0.16.6
+	sigs.k8s.io/controller-runtime v0.4.0
+	sigs.k8s.io/yaml v1.1.0
 )
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/microkit/command"
@@ -113,6 +114,13 @@ func mainWithError() error {
 
 	daemonCommand := newCommand.DaemonCommand().CobraCommand()
 
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Cleanup.Args, nil, "Arguments of the custom cleanup command. Requires --service.cleanup.command.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Cleanup.Command, nil, "Custom command of the cleanup container. It gets the scrub script in the PV_CLEANER_SCRIPT and the volume path in the PV_CLEANER_PATH environment variable. When empty the scrub script is run with /bin/sh.")
+	daemonCommand.PersistentFlags().String(f.Service.Cleanup.Image, "busybox", "Image of the cleanup container. Pin it to a digest for reproducible cleanups. Cleanup policies may select a different image per storage class.")
+	daemonCommand.PersistentFlags().String(f.Service.Cleanup.ImagePullPolicy, "", "Pull policy of the cleanup image. When empty the Kubernetes default is used.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Cleanup.ImagePullSecrets, nil, "Names of the secrets in the cleanup namespace used to pull the cleanup image.")
+	daemonCommand.PersistentFlags().String(f.Service.Cleanup.Namespace, "kube-system", "Namespace cleanup claims, jobs and pods are created in. It must exist before the operator starts.")
+
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.Address, "http://127.0.0.1:6443", "Address used to connect to Kubernetes. When empty in-cluster config is created.")
 	daemonCommand.PersistentFlags().Bool(f.Service.Kubernetes.InCluster, false, "Whether to use the in-cluster config to authenticate with Kubernetes.")
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.KubeConfig, "", "KubeConfig used to connect to Kubernetes. When empty other settings are used.")
@@ -120,6 +128,11 @@ func mainWithError() error {
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.TLS.CrtFile, "", "Certificate file path to use to authenticate with Kubernetes.")
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.TLS.KeyFile, "", "Key file path to use to authenticate with Kubernetes.")
 
+	daemonCommand.PersistentFlags().String(f.Service.Policy.File, "", "Cleanup policy file path mapping storage classes to cleanup settings. When empty filesystem volumes get their files deleted and block volumes get their headers wiped.")
+
+	daemonCommand.PersistentFlags().Duration(f.Service.Sweeper.Interval, 10*time.Minute, "Interval between two sweeps removing orphaned cleanup claims, jobs and pods.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Sweeper.MinAge, time.Hour, "Minimum age of orphaned cleanup claims, jobs and pods before they are removed.")
+
 	newCommand.CobraCommand().Execute()
 
 	return nil
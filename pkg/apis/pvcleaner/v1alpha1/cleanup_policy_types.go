@@ -0,0 +1,165 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	kindCleanupPolicy = "CleanupPolicy"
+)
+
+// NewCleanupPolicyCRD returns a new custom resource definition for
+// CleanupPolicy. This might look something like the following.
+//
+//	apiVersion: apiextensions.k8s.io/v1beta1
+//	kind: CustomResourceDefinition
+//	metadata:
+//	  name: cleanuppolicies.pvcleaner.giantswarm.io
+//	spec:
+//	  group: pvcleaner.giantswarm.io
+//	  scope: Cluster
+//	  version: v1alpha1
+//	  names:
+//	    kind: CleanupPolicy
+//	    plural: cleanuppolicies
+//	    singular: cleanuppolicy
+func NewCleanupPolicyCRD() *apiextensionsv1beta1.CustomResourceDefinition {
+	return &apiextensionsv1beta1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1beta1.SchemeGroupVersion.String(),
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cleanuppolicies.pvcleaner.giantswarm.io",
+		},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group:   group,
+			Scope:   apiextensionsv1beta1.ClusterScoped,
+			Version: version,
+			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+				Kind:     kindCleanupPolicy,
+				ListKind: "CleanupPolicyList",
+				Plural:   "cleanuppolicies",
+				Singular: "cleanuppolicy",
+			},
+			AdditionalPrinterColumns: []apiextensionsv1beta1.CustomResourceColumnDefinition{
+				{Name: "Priority", Type: "integer", JSONPath: ".spec.priority"},
+				{Name: "Strategy", Type: "string", JSONPath: ".spec.strategy"},
+				{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+			},
+		},
+	}
+}
+
+func NewCleanupPolicyTypeMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{
+		APIVersion: SchemeGroupVersion.String(),
+		Kind:       kindCleanupPolicy,
+	}
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CleanupPolicy selects persistent volumes which are cleaned once they are
+// released and defines how they are scrubbed. Policies are evaluated in
+// priority order, the first policy selecting a volume applies.
+//
+//	apiVersion: pvcleaner.giantswarm.io/v1alpha1
+//	kind: CleanupPolicy
+//	metadata:
+//	  name: tenant-volumes
+//	spec:
+//	  priority: 100
+//	  selector:
+//	    claimNamespaces:
+//	    - tenant-a
+//	    storageClasses:
+//	    - local-storage
+//	    volumeSources:
+//	    - local
+//	  strategy: secure-wipe
+//	  gracePeriod: 30m
+//	  retries: 5
+//	  job:
+//	    image: quay.io/giantswarm/busybox:1.31.1
+//	    timeout: 1h
+//	    nodeSelector:
+//	      node.giantswarm.io/storage: "true"
+type CleanupPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              CleanupPolicySpec `json:"spec"`
+}
+
+type CleanupPolicySpec struct {
+	// BlockStrategy is the name of the scrub strategy used for volumes with
+	// volume mode Block.
+	BlockStrategy string `json:"blockStrategy,omitempty"`
+	// GracePeriod is the time a released volume is kept untouched before it
+	// gets scrubbed.
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+	// Job configures the cleanup job.
+	Job CleanupPolicySpecJob `json:"job,omitempty"`
+	// Parameters are the parameters of the scrub strategy.
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// Priority orders the policies. Policies with higher priority are
+	// evaluated first, policies with equal priority by name.
+	Priority int `json:"priority"`
+	// Retries is the number of times a failed cleanup job is recreated before
+	// the volume is marked as failed.
+	Retries *int `json:"retries,omitempty"`
+	// Selector selects the volumes the policy applies to.
+	Selector CleanupPolicySpecSelector `json:"selector"`
+	// Strategy is the name of the scrub strategy used for volumes with volume
+	// mode Filesystem.
+	Strategy string `json:"strategy,omitempty"`
+}
+
+// CleanupPolicySpecSelector selects persistent volumes. Empty fields match
+// every volume, a volume must match all fields to be selected.
+type CleanupPolicySpecSelector struct {
+	// ClaimNamespaces are the namespaces of the claim the volume is bound
+	// to, or was bound to before it got released.
+	ClaimNamespaces []string `json:"claimNamespaces,omitempty"`
+	// StorageClasses are the storage classes of the volume.
+	StorageClasses []string `json:"storageClasses,omitempty"`
+	// VolumeLabels selects the labels of the volume.
+	VolumeLabels *metav1.LabelSelector `json:"volumeLabels,omitempty"`
+	// VolumeSources are the types of the volume source, named like their
+	// field in the persistent volume spec, e.g. csi, hostPath or local.
+	VolumeSources []string `json:"volumeSources,omitempty"`
+}
+
+type CleanupPolicySpecJob struct {
+	// Affinity are the scheduling constraints of the cleanup pod.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// FSGroup is the supplemental group applied to the cleanup pod volumes.
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+	// Image is the container image running the cleanup job.
+	Image string `json:"image,omitempty"`
+	// NodeSelector selects the nodes the cleanup pod may run on.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// PriorityClassName is the priority class of the cleanup pod.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Resources are the compute resources of the cleanup job container.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// RunAsUser is the user the scrub command runs as.
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// SeccompProfile is the seccomp profile of the cleanup pod.
+	SeccompProfile string `json:"seccompProfile,omitempty"`
+	// Timeout is the time the cleanup job may run before it is considered
+	// failed.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+	// Tolerations allow the cleanup pod to run on tainted nodes.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CleanupPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CleanupPolicy `json:"items"`
+}
@@ -0,0 +1,6 @@
+// +k8s:deepcopy-gen=package,register
+
+// Package v1alpha1 contains the custom resources of the pv-cleaner-operator.
+//
+// +groupName=pvcleaner.giantswarm.io
+package v1alpha1
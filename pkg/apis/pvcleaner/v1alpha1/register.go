@@ -0,0 +1,44 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	group   = "pvcleaner.giantswarm.io"
+	version = "v1alpha1"
+)
+
+// knownTypes is the full list of objects to register with the scheme. It
+// should contain all zero values of custom objects and custom object lists
+// in the group version.
+var knownTypes = []runtime.Object{
+	&CleanupPolicy{},
+	&CleanupPolicyList{},
+	&VolumeScrub{},
+	&VolumeScrubList{},
+	&VolumeScrubRequest{},
+	&VolumeScrubRequestList{},
+}
+
+// SchemeGroupVersion is group version used to register these objects.
+var SchemeGroupVersion = schema.GroupVersion{
+	Group:   group,
+	Version: version,
+}
+
+var (
+	schemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+
+	// AddToScheme adds the custom resources to the given scheme.
+	AddToScheme = schemeBuilder.AddToScheme
+)
+
+// addKnownTypes adds the list of known types to the given scheme.
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(SchemeGroupVersion, knownTypes...)
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
+	return nil
+}
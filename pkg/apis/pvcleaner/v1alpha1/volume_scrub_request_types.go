@@ -0,0 +1,122 @@
+package v1alpha1
+
+import (
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	kindVolumeScrubRequest = "VolumeScrubRequest"
+)
+
+// VolumeScrubRequestPhase is the phase of a scrub request.
+type VolumeScrubRequestPhase string
+
+const (
+	// VolumeScrubRequestPhaseFailed means the volume could not be scrubbed.
+	VolumeScrubRequestPhaseFailed VolumeScrubRequestPhase = "Failed"
+	// VolumeScrubRequestPhasePending means the cleanup of the volume was
+	// requested and waits to be picked up.
+	VolumeScrubRequestPhasePending VolumeScrubRequestPhase = "Pending"
+	// VolumeScrubRequestPhaseRejected means the volume can not be scrubbed
+	// on demand, e.g. because it is bound.
+	VolumeScrubRequestPhaseRejected VolumeScrubRequestPhase = "Rejected"
+	// VolumeScrubRequestPhaseRunning means the volume is being cleaned.
+	VolumeScrubRequestPhaseRunning VolumeScrubRequestPhase = "Running"
+	// VolumeScrubRequestPhaseSucceeded means the volume was scrubbed and is
+	// available again.
+	VolumeScrubRequestPhaseSucceeded VolumeScrubRequestPhase = "Succeeded"
+)
+
+// NewVolumeScrubRequestCRD returns a new custom resource definition for
+// VolumeScrubRequest. This might look something like the following.
+//
+//	apiVersion: apiextensions.k8s.io/v1beta1
+//	kind: CustomResourceDefinition
+//	metadata:
+//	  name: volumescrubrequests.pvcleaner.giantswarm.io
+//	spec:
+//	  group: pvcleaner.giantswarm.io
+//	  scope: Cluster
+//	  version: v1alpha1
+//	  names:
+//	    kind: VolumeScrubRequest
+//	    plural: volumescrubrequests
+//	    singular: volumescrubrequest
+//	  subresources:
+//	    status: {}
+func NewVolumeScrubRequestCRD() *apiextensionsv1beta1.CustomResourceDefinition {
+	return &apiextensionsv1beta1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1beta1.SchemeGroupVersion.String(),
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "volumescrubrequests.pvcleaner.giantswarm.io",
+		},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group:   group,
+			Scope:   apiextensionsv1beta1.ClusterScoped,
+			Version: version,
+			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+				Kind:     kindVolumeScrubRequest,
+				ListKind: "VolumeScrubRequestList",
+				Plural:   "volumescrubrequests",
+				Singular: "volumescrubrequest",
+			},
+			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+			},
+			AdditionalPrinterColumns: []apiextensionsv1beta1.CustomResourceColumnDefinition{
+				{Name: "Volume", Type: "string", JSONPath: ".spec.volumeName"},
+				{Name: "Phase", Type: "string", JSONPath: ".status.phase"},
+				{Name: "Recycle State", Type: "string", JSONPath: ".status.recycleState"},
+				{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+			},
+		},
+	}
+}
+
+func NewVolumeScrubRequestTypeMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{
+		APIVersion: SchemeGroupVersion.String(),
+		Kind:       kindVolumeScrubRequest,
+	}
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VolumeScrubRequest requests the cleanup of an available or released
+// persistent volume, independent of the volume being released.
+type VolumeScrubRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              VolumeScrubRequestSpec   `json:"spec"`
+	Status            VolumeScrubRequestStatus `json:"status"`
+}
+
+type VolumeScrubRequestSpec struct {
+	// VolumeName is the name of the persistent volume to scrub.
+	VolumeName string `json:"volumeName"`
+}
+
+type VolumeScrubRequestStatus struct {
+	// AcceptedAt is the time the cleanup of the volume was requested.
+	AcceptedAt *metav1.Time `json:"acceptedAt,omitempty"`
+	// CompletedAt is the time the request succeeded, failed or got rejected.
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+	// Message describes the progress of the request.
+	Message string `json:"message,omitempty"`
+	// Phase may be Pending, Running, Succeeded, Failed or Rejected.
+	Phase VolumeScrubRequestPhase `json:"phase,omitempty"`
+	// RecycleState is the recycle state of the volume as last observed.
+	RecycleState string `json:"recycleState,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type VolumeScrubRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []VolumeScrubRequest `json:"items"`
+}
@@ -0,0 +1,142 @@
+package v1alpha1
+
+import (
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	kindVolumeScrub = "VolumeScrub"
+)
+
+// VolumeScrubPhase is the phase of a cleanup attempt.
+type VolumeScrubPhase string
+
+const (
+	// VolumeScrubPhaseFailed means the cleanup job failed, or was aborted
+	// before it finished.
+	VolumeScrubPhaseFailed VolumeScrubPhase = "Failed"
+	// VolumeScrubPhaseRunning means the cleanup job was created and did not
+	// finish yet.
+	VolumeScrubPhaseRunning VolumeScrubPhase = "Running"
+	// VolumeScrubPhaseSucceeded means the cleanup job scrubbed the volume.
+	VolumeScrubPhaseSucceeded VolumeScrubPhase = "Succeeded"
+)
+
+// NewVolumeScrubCRD returns a new custom resource definition for
+// VolumeScrub. This might look something like the following.
+//
+//	apiVersion: apiextensions.k8s.io/v1beta1
+//	kind: CustomResourceDefinition
+//	metadata:
+//	  name: volumescrubs.pvcleaner.giantswarm.io
+//	spec:
+//	  group: pvcleaner.giantswarm.io
+//	  scope: Cluster
+//	  version: v1alpha1
+//	  names:
+//	    kind: VolumeScrub
+//	    plural: volumescrubs
+//	    singular: volumescrub
+//	  subresources:
+//	    status: {}
+func NewVolumeScrubCRD() *apiextensionsv1beta1.CustomResourceDefinition {
+	return &apiextensionsv1beta1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1beta1.SchemeGroupVersion.String(),
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "volumescrubs.pvcleaner.giantswarm.io",
+		},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group:   group,
+			Scope:   apiextensionsv1beta1.ClusterScoped,
+			Version: version,
+			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+				Kind:     kindVolumeScrub,
+				ListKind: "VolumeScrubList",
+				Plural:   "volumescrubs",
+				Singular: "volumescrub",
+			},
+			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+			},
+			AdditionalPrinterColumns: []apiextensionsv1beta1.CustomResourceColumnDefinition{
+				{Name: "Volume", Type: "string", JSONPath: ".spec.volumeName"},
+				{Name: "Strategy", Type: "string", JSONPath: ".spec.strategy"},
+				{Name: "Phase", Type: "string", JSONPath: ".status.phase"},
+				{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+			},
+		},
+	}
+}
+
+func NewVolumeScrubTypeMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{
+		APIVersion: SchemeGroupVersion.String(),
+		Kind:       kindVolumeScrub,
+	}
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VolumeScrub records a single cleanup attempt of a persistent volume. It
+// outlives the cleanup job and its pods, which are removed once the attempt
+// finished.
+type VolumeScrub struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              VolumeScrubSpec   `json:"spec"`
+	Status            VolumeScrubStatus `json:"status"`
+}
+
+type VolumeScrubSpec struct {
+	// Attempt is the number of the cleanup attempt within the recycling of
+	// the volume, starting at 1.
+	Attempt int `json:"attempt"`
+	// Image is the image the cleanup job runs.
+	Image string `json:"image"`
+	// JobName is the name of the cleanup job.
+	JobName string `json:"jobName"`
+	// PreviousClaim is the claim the volume was bound to before it got
+	// released.
+	PreviousClaim VolumeScrubSpecClaim `json:"previousClaim"`
+	// StorageClass is the storage class of the volume.
+	StorageClass string `json:"storageClass"`
+	// Strategy is the scrub strategy of the cleanup job.
+	Strategy string `json:"strategy"`
+	// VolumeName is the name of the scrubbed persistent volume.
+	VolumeName string `json:"volumeName"`
+}
+
+type VolumeScrubSpecClaim struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type VolumeScrubStatus struct {
+	// BytesRemoved is the amount of data the cleanup job removed, if the
+	// strategy reports it.
+	BytesRemoved *int64 `json:"bytesRemoved,omitempty"`
+	// CompletedAt is the time the cleanup attempt finished.
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+	// ExitCode is the exit code of the last cleanup container.
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// Message describes why the cleanup attempt failed.
+	Message string `json:"message,omitempty"`
+	// Phase may be Running, Succeeded or Failed.
+	Phase VolumeScrubPhase `json:"phase"`
+	// Reason is the reason the cleanup attempt failed.
+	Reason string `json:"reason,omitempty"`
+	// StartedAt is the time the cleanup job was created.
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type VolumeScrubList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []VolumeScrub `json:"items"`
+}
@@ -0,0 +1,121 @@
+// Package cleaner provides the scrub strategies used to wipe released
+// persistent volumes.
+package cleaner
+
+import (
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DeleteFiles removes all files from a filesystem volume.
+	DeleteFiles = "delete-files"
+	// Discard discards all blocks of a block volume.
+	Discard = "discard"
+	// Reformat creates a new filesystem on a block volume. Filesystem volumes
+	// are mounted into the cleanup pod and can not be reformatted, they are
+	// scrubbed with DeleteFiles or ZeroFill instead.
+	Reformat = "reformat"
+	// SecureWipe overwrites the volume several times with random data or
+	// zeros before its files are removed. See PassesParameter,
+	// SourceParameter and TargetParameter.
+	SecureWipe = "secure-wipe"
+	// Verify does not modify the volume and only checks that it is empty.
+	Verify = "verify"
+	// WipeHeaders overwrites the first and last MiB of a block volume, which
+	// removes partition tables and filesystem signatures.
+	WipeHeaders = "wipe-headers"
+	// ZeroFill overwrites the volume with zeros. Filesystem volumes get their
+	// files removed and their free space overwritten.
+	ZeroFill = "zero-fill"
+)
+
+const (
+	// CleanupLabel marks the cleanup claims, cleanup jobs and cleanup pods
+	// created by the operator.
+	CleanupLabel = "pv-cleaner-operator.giantswarm.io/cleanup"
+	// CleanupLabelValue is the value of CleanupLabel.
+	CleanupLabelValue = "true"
+	// ImageDigestAnnotation is the annotation of cleanup jobs holding the
+	// digest of the image running the scrub command.
+	ImageDigestAnnotation = "pv-cleaner-operator.giantswarm.io/image-digest"
+	// MethodAnnotation is the annotation of cleanup jobs describing the scrub
+	// strategy and its parameters used by the job.
+	MethodAnnotation = "pv-cleaner-operator.giantswarm.io/cleanup-method"
+	// VolumeAnnotation is the annotation of cleanup claims, cleanup jobs and
+	// cleanup pods holding the name of the persistent volume they clean.
+	VolumeAnnotation = "pv-cleaner-operator.giantswarm.io/persistent-volume"
+)
+
+// Result describes the outcome of a cleanup job.
+type Result string
+
+const (
+	ResultFailed    Result = "Failed"
+	ResultRunning   Result = "Running"
+	ResultSucceeded Result = "Succeeded"
+)
+
+// Interface is implemented by every scrub strategy.
+type Interface interface {
+	// Name returns the strategy name used to select the cleaner.
+	Name() string
+	// NewJob returns the job which scrubs the volume bound to the claim given
+	// in config.
+	NewJob(config JobConfig) (*batchv1.Job, error)
+	// Result interprets the status of a job created by NewJob.
+	Result(job *batchv1.Job) Result
+}
+
+// ContainerConfig describes the container running the scrub command,
+// independent of the volume being scrubbed.
+type ContainerConfig struct {
+	// Args overrides the arguments of Command.
+	Args []string
+	// Command overrides the command running the scrub script. The script is
+	// provided in the PV_CLEANER_SCRIPT environment variable and the path the
+	// volume is attached to in the PV_CLEANER_PATH environment variable.
+	// Defaults to running the script with /bin/sh.
+	Command []string
+	// Image is the container image running the scrub command. Defaults to
+	// busybox.
+	Image string
+	// ImagePullPolicy is the pull policy of Image. Defaults to the Kubernetes
+	// default.
+	ImagePullPolicy apiv1.PullPolicy
+	// ImagePullSecrets are the names of the secrets used to pull Image. They
+	// must exist in the namespace of the job.
+	ImagePullSecrets []string
+}
+
+// JobConfig describes the cleanup job a cleaner has to build.
+type JobConfig struct {
+	// Claim is the cleanup claim bound to the volume being scrubbed.
+	Claim *apiv1.PersistentVolumeClaim
+	// Container configures the container running the scrub command.
+	Container ContainerConfig
+	// Parameters are the strategy specific parameters.
+	Parameters map[string]string
+	// Pod configures scheduling and security settings of the cleanup pod.
+	Pod PodConfig
+	// Resources are the compute resources of the scrub container.
+	Resources apiv1.ResourceRequirements
+	// Timeout is the time the job may be active before it is marked as
+	// failed. Zero means no timeout.
+	Timeout time.Duration
+}
+
+// Builtin returns all scrub strategies shipped with the operator.
+func Builtin() []Interface {
+	return []Interface{
+		&deleteFiles{},
+		&discard{},
+		&reformat{},
+		&secureWipe{},
+		&verify{},
+		&wipeHeaders{},
+		&zeroFill{},
+	}
+}
@@ -0,0 +1,390 @@
+package cleaner
+
+import (
+	"reflect"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Cleaner_NewJob(t *testing.T) {
+	filesystem := apiv1.PersistentVolumeFilesystem
+	block := apiv1.PersistentVolumeBlock
+
+	testCases := []struct {
+		description  string
+		strategy     string
+		volumeMode   *apiv1.PersistentVolumeMode
+		expectMount  bool
+		errorMatcher func(error) bool
+	}{
+		{
+			description: "delete-files mounts claim without volume mode",
+			strategy:    DeleteFiles,
+			volumeMode:  nil,
+			expectMount: true,
+		},
+		{
+			description: "zero-fill mounts filesystem claim",
+			strategy:    ZeroFill,
+			volumeMode:  &filesystem,
+			expectMount: true,
+		},
+		{
+			description: "verify mounts filesystem claim",
+			strategy:    Verify,
+			volumeMode:  &filesystem,
+			expectMount: true,
+		},
+		{
+			description:  "reformat rejects filesystem claim",
+			strategy:     Reformat,
+			volumeMode:   &filesystem,
+			errorMatcher: IsUnsupportedVolumeMode,
+		},
+		{
+			description:  "delete-files rejects block claim",
+			strategy:     DeleteFiles,
+			volumeMode:   &block,
+			errorMatcher: IsUnsupportedVolumeMode,
+		},
+		{
+			description:  "discard rejects filesystem claim",
+			strategy:     Discard,
+			volumeMode:   nil,
+			errorMatcher: IsUnsupportedVolumeMode,
+		},
+		{
+			description: "discard attaches block claim as device",
+			strategy:    Discard,
+			volumeMode:  &block,
+			expectMount: false,
+		},
+		{
+			description: "wipe-headers attaches block claim as device",
+			strategy:    WipeHeaders,
+			volumeMode:  &block,
+			expectMount: false,
+		},
+		{
+			description: "zero-fill attaches block claim as device",
+			strategy:    ZeroFill,
+			volumeMode:  &block,
+			expectMount: false,
+		},
+		{
+			description: "verify attaches block claim as device",
+			strategy:    Verify,
+			volumeMode:  &block,
+			expectMount: false,
+		},
+		{
+			description: "reformat attaches block claim as device",
+			strategy:    Reformat,
+			volumeMode:  &block,
+			expectMount: false,
+		},
+	}
+
+	cleaners := map[string]Interface{}
+	for _, c := range Builtin() {
+		cleaners[c.Name()] = c
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			claim := &apiv1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "pv-cleaner-claim-TestPersistentVolume",
+				},
+				Spec: apiv1.PersistentVolumeClaimSpec{
+					VolumeMode: tc.volumeMode,
+				},
+			}
+
+			job, err := cleaners[tc.strategy].NewJob(JobConfig{Claim: claim})
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("case %d expected error matcher to match, got %#v", i+1, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned building job: %s\n", i+1, err)
+			}
+
+			if job.Name != "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume" {
+				t.Fatalf("case %d expected job name %#q got %#q", i+1, "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume", job.Name)
+			}
+
+			container := job.Spec.Template.Spec.Containers[0]
+			if tc.expectMount && (len(container.VolumeMounts) != 1 || len(container.VolumeDevices) != 0) {
+				t.Fatalf("case %d expected claim to be mounted, got mounts %#v devices %#v", i+1, container.VolumeMounts, container.VolumeDevices)
+			}
+			if !tc.expectMount && (len(container.VolumeMounts) != 0 || len(container.VolumeDevices) != 1) {
+				t.Fatalf("case %d expected claim to be attached as device, got mounts %#v devices %#v", i+1, container.VolumeMounts, container.VolumeDevices)
+			}
+		})
+	}
+}
+
+func Test_Cleaner_Result(t *testing.T) {
+	testCases := []struct {
+		description    string
+		status         batchv1.JobStatus
+		expectedResult Result
+	}{
+		{
+			description:    "job without completed pods is running",
+			status:         batchv1.JobStatus{Active: 1},
+			expectedResult: ResultRunning,
+		},
+		{
+			description:    "job with succeeded pod succeeded",
+			status:         batchv1.JobStatus{Succeeded: 1},
+			expectedResult: ResultSucceeded,
+		},
+		{
+			description: "job with failed condition failed",
+			status: batchv1.JobStatus{
+				Failed: 6,
+				Conditions: []batchv1.JobCondition{
+					{
+						Type:   batchv1.JobFailed,
+						Status: apiv1.ConditionTrue,
+					},
+				},
+			},
+			expectedResult: ResultFailed,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			for _, c := range Builtin() {
+				result := c.Result(&batchv1.Job{Status: tc.status})
+				if result != tc.expectedResult {
+					t.Fatalf("case %d strategy %#q expected %#q got %#q", i+1, c.Name(), tc.expectedResult, result)
+				}
+			}
+		})
+	}
+}
+
+func Test_Cleaner_SecureWipe(t *testing.T) {
+	block := apiv1.PersistentVolumeBlock
+
+	testCases := []struct {
+		description    string
+		parameters     map[string]string
+		volumeMode     *apiv1.PersistentVolumeMode
+		expectedMethod string
+		errorMatcher   func(error) bool
+	}{
+		{
+			description:    "defaults overwrite files three times with random data",
+			parameters:     nil,
+			expectedMethod: "secure-wipe passes=3 source=random target=files",
+		},
+		{
+			description: "parameters select passes, source and target",
+			parameters: map[string]string{
+				PassesParameter: "7",
+				SourceParameter: "zero",
+				TargetParameter: "free-space",
+			},
+			expectedMethod: "secure-wipe passes=7 source=zero target=free-space",
+		},
+		{
+			description: "block volumes are overwritten as a whole",
+			parameters: map[string]string{
+				PassesParameter: "1",
+			},
+			volumeMode:     &block,
+			expectedMethod: "secure-wipe passes=1 source=random",
+		},
+		{
+			description: "passes must be positive",
+			parameters: map[string]string{
+				PassesParameter: "0",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			description: "source must be known",
+			parameters: map[string]string{
+				SourceParameter: "ones",
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	c := &secureWipe{}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			config := JobConfig{
+				Claim: &apiv1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "pv-cleaner-claim-TestPersistentVolume",
+					},
+					Spec: apiv1.PersistentVolumeClaimSpec{
+						VolumeMode: tc.volumeMode,
+					},
+				},
+				Parameters: tc.parameters,
+			}
+
+			job, err := c.NewJob(config)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("case %d expected error matcher to match, got %#v", i+1, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned building job: %s\n", i+1, err)
+			}
+
+			method := job.Annotations[MethodAnnotation]
+			if method != tc.expectedMethod {
+				t.Fatalf("case %d expected method %#q got %#q", i+1, tc.expectedMethod, method)
+			}
+		})
+	}
+}
+
+func Test_Cleaner_NewJob_Container(t *testing.T) {
+	testCases := []struct {
+		description      string
+		container        ContainerConfig
+		expectedImage    string
+		expectedCommand  []string
+		expectedDigest   string
+		expectedSecrets  []apiv1.LocalObjectReference
+		expectedEnvCount int
+		errorMatcher     func(error) bool
+	}{
+		{
+			description:     "default container runs script with busybox",
+			container:       ContainerConfig{},
+			expectedImage:   "busybox",
+			expectedCommand: []string{"/bin/sh", "-c"},
+		},
+		{
+			description: "image pinned to digest is recorded",
+			container: ContainerConfig{
+				Image:            "registry.example.com/busybox@sha256:4a35",
+				ImagePullSecrets: []string{"registry-pull-secret"},
+			},
+			expectedImage:   "registry.example.com/busybox@sha256:4a35",
+			expectedCommand: []string{"/bin/sh", "-c"},
+			expectedDigest:  "sha256:4a35",
+			expectedSecrets: []apiv1.LocalObjectReference{{Name: "registry-pull-secret"}},
+		},
+		{
+			description: "custom command gets script from environment",
+			container: ContainerConfig{
+				Args:    []string{"--audit"},
+				Command: []string{"/usr/local/bin/scrub"},
+				Image:   "registry.example.com/scrub:1.0.0",
+			},
+			expectedImage:    "registry.example.com/scrub:1.0.0",
+			expectedCommand:  []string{"/usr/local/bin/scrub"},
+			expectedEnvCount: 2,
+		},
+		{
+			description: "args without command are rejected",
+			container: ContainerConfig{
+				Args: []string{"--audit"},
+			},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			claim := &apiv1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "pv-cleaner-claim-TestPersistentVolume",
+				},
+			}
+
+			job, err := (&deleteFiles{}).NewJob(JobConfig{Claim: claim, Container: tc.container})
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("case %d expected error matcher to match, got %#v", i+1, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned building job: %s\n", i+1, err)
+			}
+
+			container := job.Spec.Template.Spec.Containers[0]
+			if container.Image != tc.expectedImage {
+				t.Fatalf("case %d expected image %#q got %#q", i+1, tc.expectedImage, container.Image)
+			}
+			if !reflect.DeepEqual(container.Command[:len(tc.expectedCommand)], tc.expectedCommand) {
+				t.Fatalf("case %d expected command %#v got %#v", i+1, tc.expectedCommand, container.Command)
+			}
+			if len(container.Env) != tc.expectedEnvCount {
+				t.Fatalf("case %d expected %d environment variables got %#v", i+1, tc.expectedEnvCount, container.Env)
+			}
+			if job.Annotations[ImageDigestAnnotation] != tc.expectedDigest {
+				t.Fatalf("case %d expected image digest %#q got %#q", i+1, tc.expectedDigest, job.Annotations[ImageDigestAnnotation])
+			}
+			if !reflect.DeepEqual(job.Spec.Template.Spec.ImagePullSecrets, tc.expectedSecrets) {
+				t.Fatalf("case %d expected image pull secrets %#v got %#v", i+1, tc.expectedSecrets, job.Spec.Template.Spec.ImagePullSecrets)
+			}
+		})
+	}
+}
+
+func Test_Cleaner_ReclaimedBytes(t *testing.T) {
+	testCases := []struct {
+		description    string
+		message        string
+		expectedBytes  int64
+		expectedResult bool
+	}{
+		{
+			description:    "reported bytes are parsed",
+			message:        "reclaimed-bytes=1048576\n",
+			expectedBytes:  1048576,
+			expectedResult: true,
+		},
+		{
+			description:    "empty message reports nothing",
+			message:        "",
+			expectedBytes:  0,
+			expectedResult: false,
+		},
+		{
+			description:    "failure message reports nothing",
+			message:        "rm: can't remove '/scrub/data': Read-only file system",
+			expectedBytes:  0,
+			expectedResult: false,
+		},
+		{
+			description:    "invalid number reports nothing",
+			message:        "reclaimed-bytes=-1",
+			expectedBytes:  0,
+			expectedResult: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			bytes, ok := ReclaimedBytes(tc.message)
+			if ok != tc.expectedResult {
+				t.Fatalf("case %d expected result %t got %t", i+1, tc.expectedResult, ok)
+			}
+			if bytes != tc.expectedBytes {
+				t.Fatalf("case %d expected %d bytes got %d", i+1, tc.expectedBytes, bytes)
+			}
+		})
+	}
+}
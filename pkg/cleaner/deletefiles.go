@@ -0,0 +1,31 @@
+package cleaner
+
+import (
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// deleteFiles removes all files, including hidden ones, from the volume and
+// checks that the volume is empty afterwards. The space used by the removed
+// files is reported as reclaimed bytes.
+type deleteFiles struct{}
+
+func (c *deleteFiles) Name() string {
+	return DeleteFiles
+}
+
+func (c *deleteFiles) NewJob(config JobConfig) (*batchv1.Job, error) {
+	err := validateJobConfig(config, c.Name(), apiv1.PersistentVolumeFilesystem)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	script := "test -e /scrub && used=$(du -sk /scrub | cut -f1) && rm -rf /scrub/..?* /scrub/.[!.]* /scrub/*  && test -z \"$(ls -A /scrub)\" && echo \"" + reclaimedBytesPrefix + "$(( used * 1024 ))\" > /dev/termination-log || exit 1"
+
+	return newJob(config, c.Name(), script), nil
+}
+
+func (c *deleteFiles) Result(job *batchv1.Job) Result {
+	return jobResult(job)
+}
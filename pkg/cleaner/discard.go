@@ -0,0 +1,30 @@
+package cleaner
+
+import (
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// discard tells the storage backend that all blocks of a block volume are
+// unused. Whether discarded blocks read back as zeros depends on the device.
+type discard struct{}
+
+func (c *discard) Name() string {
+	return Discard
+}
+
+func (c *discard) NewJob(config JobConfig) (*batchv1.Job, error) {
+	err := validateJobConfig(config, c.Name(), apiv1.PersistentVolumeBlock)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	script := "test -b /dev/scrub && blkdiscard /dev/scrub || exit 1"
+
+	return newJob(config, c.Name(), script), nil
+}
+
+func (c *discard) Result(job *batchv1.Job) Result {
+	return jobResult(job)
+}
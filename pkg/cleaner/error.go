@@ -0,0 +1,23 @@
+package cleaner
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var unsupportedVolumeModeError = &microerror.Error{
+	Kind: "unsupportedVolumeModeError",
+}
+
+// IsUnsupportedVolumeMode asserts unsupportedVolumeModeError.
+func IsUnsupportedVolumeMode(err error) bool {
+	return microerror.Cause(err) == unsupportedVolumeModeError
+}
@@ -0,0 +1,245 @@
+package cleaner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultImage = "busybox"
+
+	// pathEnv is the environment variable holding the path the claim is
+	// attached to, for custom commands.
+	pathEnv = "PV_CLEANER_PATH"
+	// scriptEnv is the environment variable holding the scrub script, for
+	// custom commands.
+	scriptEnv = "PV_CLEANER_SCRIPT"
+
+	// devicePath is the path the claim is attached to in block mode.
+	devicePath = "/dev/scrub"
+	// mountPath is the path the claim is mounted to in filesystem mode.
+	mountPath  = "/scrub"
+	volumeName = "pv-cleaner-mount"
+
+	// reclaimedBytesPrefix prefixes the number of reclaimed bytes in the
+	// termination message of scrub scripts which measure them.
+	reclaimedBytesPrefix = "reclaimed-bytes="
+)
+
+// JobName returns the name of the cleanup job for the given claim.
+func JobName(claim *apiv1.PersistentVolumeClaim) string {
+	return fmt.Sprintf("pv-cleaner-job-%s", claim.Name)
+}
+
+// ImageDigest returns the digest of the given image reference or image ID,
+// e.g. sha256:4a35... for busybox@sha256:4a35... or
+// docker-pullable://busybox@sha256:4a35.... Image references which are not
+// pinned to a digest result in an empty string.
+func ImageDigest(image string) string {
+	if strings.HasPrefix(image, "sha256:") {
+		return image
+	}
+
+	i := strings.LastIndex(image, "@")
+	if i < 0 {
+		return ""
+	}
+
+	return image[i+1:]
+}
+
+// ReclaimedBytes returns the number of bytes reclaimed by a cleanup job, as
+// reported in the given termination message of its container. Strategies
+// which do not measure reclaimed space report nothing, in which case false is
+// returned.
+func ReclaimedBytes(message string) (int64, bool) {
+	message = strings.TrimSpace(message)
+	if !strings.HasPrefix(message, reclaimedBytesPrefix) {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(strings.TrimPrefix(message, reclaimedBytesPrefix), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// volumeMode returns the volume mode requested by the claim. Claims without
+// an explicit volume mode are filesystem claims.
+func volumeMode(claim *apiv1.PersistentVolumeClaim) apiv1.PersistentVolumeMode {
+	if claim.Spec.VolumeMode == nil {
+		return apiv1.PersistentVolumeFilesystem
+	}
+	return *claim.Spec.VolumeMode
+}
+
+// newJob returns k8s job object, which runs the configured image, attaches
+// claim from the function parameter and runs the given shell script against it.
+// Filesystem claims are mounted at /scrub, block claims are attached as
+// device at /dev/scrub. The given method is recorded in the method annotation
+// of the job.
+func newJob(config JobConfig, method, script string) *batchv1.Job {
+	image := config.Container.Image
+	if image == "" {
+		image = defaultImage
+	}
+
+	container := apiv1.Container{
+		Name:  fmt.Sprintf("pv-cleaner-container-%s", config.Claim.Name),
+		Image: image,
+		Command: []string{
+			"/bin/sh",
+			"-c",
+			script,
+		},
+		ImagePullPolicy:          config.Container.ImagePullPolicy,
+		Resources:                config.Resources,
+		TerminationMessagePolicy: apiv1.TerminationMessageFallbackToLogsOnError,
+	}
+
+	if len(config.Container.Command) > 0 {
+		path := mountPath
+		if volumeMode(config.Claim) == apiv1.PersistentVolumeBlock {
+			path = devicePath
+		}
+
+		container.Command = config.Container.Command
+		container.Args = config.Container.Args
+		container.Env = []apiv1.EnvVar{
+			{
+				Name:  pathEnv,
+				Value: path,
+			},
+			{
+				Name:  scriptEnv,
+				Value: script,
+			},
+		}
+	}
+
+	var pullSecrets []apiv1.LocalObjectReference
+	for _, s := range config.Container.ImagePullSecrets {
+		pullSecrets = append(pullSecrets, apiv1.LocalObjectReference{Name: s})
+	}
+
+	if volumeMode(config.Claim) == apiv1.PersistentVolumeBlock {
+		container.VolumeDevices = []apiv1.VolumeDevice{
+			{
+				Name:       volumeName,
+				DevicePath: devicePath,
+			},
+		}
+	} else {
+		container.VolumeMounts = []apiv1.VolumeMount{
+			{
+				Name:      volumeName,
+				MountPath: mountPath,
+			},
+		}
+	}
+
+	backoffLimit := int32(0)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: JobName(config.Claim),
+			Annotations: map[string]string{
+				MethodAnnotation: method,
+				VolumeAnnotation: config.Claim.Spec.VolumeName,
+			},
+			Labels: map[string]string{
+				CleanupLabel: CleanupLabelValue,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			// Failed jobs are retried by the operator, which tracks the retries on
+			// the volume.
+			BackoffLimit: &backoffLimit,
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "pv-cleaner-pod",
+					Annotations: map[string]string{
+						VolumeAnnotation: config.Claim.Spec.VolumeName,
+					},
+					Labels: map[string]string{
+						CleanupLabel: CleanupLabelValue,
+					},
+				},
+				Spec: apiv1.PodSpec{
+					Containers: []apiv1.Container{
+						container,
+					},
+					ImagePullSecrets: pullSecrets,
+					RestartPolicy:    apiv1.RestartPolicyNever,
+					Volumes: []apiv1.Volume{
+						{
+							Name: volumeName,
+							VolumeSource: apiv1.VolumeSource{
+								PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{
+									ClaimName: config.Claim.Name,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	applyPodConfig(&job.Spec.Template, config.Pod)
+
+	if config.Timeout > 0 {
+		seconds := int64(config.Timeout.Seconds())
+		job.Spec.ActiveDeadlineSeconds = &seconds
+	}
+
+	if digest := ImageDigest(image); digest != "" {
+		job.Annotations[ImageDigestAnnotation] = digest
+	}
+
+	return job
+}
+
+// jobResult interprets the status of a cleanup job. A job succeeded once one
+// of its pods completed, and failed once the job controller marked it as
+// failed.
+func jobResult(job *batchv1.Job) Result {
+	if job.Status.Succeeded >= 1 {
+		return ResultSucceeded
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == apiv1.ConditionTrue {
+			return ResultFailed
+		}
+	}
+
+	return ResultRunning
+}
+
+// validateJobConfig checks that config carries a claim requesting one of the
+// volume modes the given strategy works with.
+func validateJobConfig(config JobConfig, strategy string, modes ...apiv1.PersistentVolumeMode) error {
+	if config.Claim == nil {
+		return microerror.Maskf(invalidConfigError, "%T.Claim must not be empty", config)
+	}
+	if len(config.Container.Args) > 0 && len(config.Container.Command) == 0 {
+		return microerror.Maskf(invalidConfigError, "%T.Container.Args must be empty without %T.Container.Command", config, config)
+	}
+
+	for _, m := range modes {
+		if volumeMode(config.Claim) == m {
+			return nil
+		}
+	}
+
+	return microerror.Maskf(unsupportedVolumeModeError, "strategy %#q does not support volume mode %#q", strategy, volumeMode(config.Claim))
+}
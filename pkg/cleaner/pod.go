@@ -0,0 +1,90 @@
+package cleaner
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// seccompPodAnnotation is the pod annotation selecting the seccomp profile of
+// all containers of the pod.
+const seccompPodAnnotation = "seccomp.security.alpha.kubernetes.io/pod"
+
+// PodConfig describes where and how cleanup pods are scheduled and run.
+//
+//	nodeSelector:
+//	  node.giantswarm.io/storage: "true"
+//	tolerations:
+//	- key: node.giantswarm.io/storage
+//	  operator: Exists
+//	  effect: NoSchedule
+//	priorityClassName: system-node-critical
+//	runAsUser: 0
+//	fsGroup: 0
+//	seccompProfile: runtime/default
+type PodConfig struct {
+	// Affinity are the scheduling constraints of the cleanup pod.
+	Affinity *apiv1.Affinity `json:"affinity,omitempty"`
+	// FSGroup is the supplemental group applied to the cleanup pod volumes.
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+	// NodeSelector selects the nodes the cleanup pod may run on.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// PriorityClassName is the priority class of the cleanup pod.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// RunAsUser is the user the scrub command runs as.
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// SeccompProfile is the seccomp profile of the cleanup pod, e.g.
+	// runtime/default.
+	SeccompProfile string `json:"seccompProfile,omitempty"`
+	// Tolerations allow the cleanup pod to run on tainted nodes.
+	Tolerations []apiv1.Toleration `json:"tolerations,omitempty"`
+}
+
+// Merge returns the pod configuration with every empty setting taken from
+// the given defaults.
+func (c PodConfig) Merge(defaults PodConfig) PodConfig {
+	if c.Affinity == nil {
+		c.Affinity = defaults.Affinity
+	}
+	if c.FSGroup == nil {
+		c.FSGroup = defaults.FSGroup
+	}
+	if c.NodeSelector == nil {
+		c.NodeSelector = defaults.NodeSelector
+	}
+	if c.PriorityClassName == "" {
+		c.PriorityClassName = defaults.PriorityClassName
+	}
+	if c.RunAsUser == nil {
+		c.RunAsUser = defaults.RunAsUser
+	}
+	if c.SeccompProfile == "" {
+		c.SeccompProfile = defaults.SeccompProfile
+	}
+	if c.Tolerations == nil {
+		c.Tolerations = defaults.Tolerations
+	}
+
+	return c
+}
+
+// applyPodConfig configures the given pod template according to the pod
+// configuration.
+func applyPodConfig(template *apiv1.PodTemplateSpec, config PodConfig) {
+	template.Spec.Affinity = config.Affinity
+	template.Spec.NodeSelector = config.NodeSelector
+	template.Spec.PriorityClassName = config.PriorityClassName
+	template.Spec.Tolerations = config.Tolerations
+
+	if config.FSGroup != nil || config.RunAsUser != nil {
+		template.Spec.SecurityContext = &apiv1.PodSecurityContext{
+			FSGroup:   config.FSGroup,
+			RunAsUser: config.RunAsUser,
+		}
+	}
+
+	if config.SeccompProfile != "" {
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[seccompPodAnnotation] = config.SeccompProfile
+	}
+}
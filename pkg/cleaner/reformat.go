@@ -0,0 +1,31 @@
+package cleaner
+
+import (
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// reformat creates a fresh ext2 filesystem on the volume using busybox
+// mke2fs. Since a filesystem cannot be recreated while it is mounted, the
+// strategy requires the claim to attach the volume as raw block device.
+type reformat struct{}
+
+func (c *reformat) Name() string {
+	return Reformat
+}
+
+func (c *reformat) NewJob(config JobConfig) (*batchv1.Job, error) {
+	err := validateJobConfig(config, c.Name(), apiv1.PersistentVolumeBlock)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	script := "test -b /dev/scrub && mke2fs -F /dev/scrub || exit 1"
+
+	return newJob(config, c.Name(), script), nil
+}
+
+func (c *reformat) Result(job *batchv1.Job) Result {
+	return jobResult(job)
+}
@@ -0,0 +1,104 @@
+package cleaner
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// PassesParameter is the number of times secure-wipe overwrites the
+	// volume. Defaults to 3.
+	PassesParameter = "passes"
+	// SourceParameter is the data secure-wipe overwrites the volume with.
+	// Either random or zero. Defaults to random.
+	SourceParameter = "source"
+	// TargetParameter is the part of a filesystem volume secure-wipe
+	// overwrites. Either files, to overwrite the content of every file before
+	// it is removed, or free-space, to overwrite the whole free space after
+	// all files got removed. Defaults to files. Block volumes are always
+	// overwritten as a whole.
+	TargetParameter = "target"
+)
+
+const (
+	defaultPasses = 3
+
+	sourceRandom = "random"
+	sourceZero   = "zero"
+
+	targetFiles     = "files"
+	targetFreeSpace = "free-space"
+)
+
+// secureWipe overwrites the volume several times with random data or zeros
+// before its files are removed.
+type secureWipe struct{}
+
+func (c *secureWipe) Name() string {
+	return SecureWipe
+}
+
+func (c *secureWipe) NewJob(config JobConfig) (*batchv1.Job, error) {
+	err := validateJobConfig(config, c.Name(), apiv1.PersistentVolumeFilesystem, apiv1.PersistentVolumeBlock)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	passes := defaultPasses
+	if v, ok := config.Parameters[PassesParameter]; ok {
+		passes, err = strconv.Atoi(v)
+		if err != nil || passes < 1 {
+			return nil, microerror.Maskf(invalidConfigError, "parameter %#q must be a positive number, got %#q", PassesParameter, v)
+		}
+	}
+
+	source := config.Parameters[SourceParameter]
+	if source == "" {
+		source = sourceRandom
+	}
+
+	var device string
+	switch source {
+	case sourceRandom:
+		device = "/dev/urandom"
+	case sourceZero:
+		device = "/dev/zero"
+	default:
+		return nil, microerror.Maskf(invalidConfigError, "parameter %#q must be %#q or %#q, got %#q", SourceParameter, sourceRandom, sourceZero, source)
+	}
+
+	target := config.Parameters[TargetParameter]
+	if target == "" {
+		target = targetFiles
+	}
+
+	switch target {
+	case targetFiles, targetFreeSpace:
+	default:
+		return nil, microerror.Maskf(invalidConfigError, "parameter %#q must be %#q or %#q, got %#q", TargetParameter, targetFiles, targetFreeSpace, target)
+	}
+
+	method := fmt.Sprintf("%s %s=%d %s=%s", c.Name(), PassesParameter, passes, SourceParameter, source)
+
+	var script string
+	if volumeMode(config.Claim) == apiv1.PersistentVolumeBlock {
+		script = fmt.Sprintf("test -b /dev/scrub && size=$(blockdev --getsize64 /dev/scrub) && for p in $(seq %d); do head -c \"$size\" %s > /dev/scrub && sync || exit 1; done || exit 1", passes, device)
+	} else if target == targetFreeSpace {
+		method = fmt.Sprintf("%s %s=%s", method, TargetParameter, target)
+		// dd exits non-zero once the filesystem is full, which is expected here.
+		script = fmt.Sprintf("test -e /scrub && rm -rf /scrub/..?* /scrub/.[!.]* /scrub/* && for p in $(seq %d); do dd if=%s of=/scrub/.pv-cleaner-fill bs=1M; sync; rm -f /scrub/.pv-cleaner-fill; done && test -z \"$(ls -A /scrub)\" || exit 1", passes, device)
+	} else {
+		method = fmt.Sprintf("%s %s=%s", method, TargetParameter, target)
+		script = fmt.Sprintf("test -e /scrub && find /scrub -type f -exec sh -c 'for f in \"$@\"; do for p in $(seq %d); do dd if=%s of=\"$f\" bs=4096 count=$(( ($(stat -c %%s \"$f\") + 4095) / 4096 )) conv=notrunc,fsync || exit 1; done; done' sh {} + && rm -rf /scrub/..?* /scrub/.[!.]* /scrub/* && test -z \"$(ls -A /scrub)\" || exit 1", passes, device)
+	}
+
+	return newJob(config, method, script), nil
+}
+
+func (c *secureWipe) Result(job *batchv1.Job) Result {
+	return jobResult(job)
+}
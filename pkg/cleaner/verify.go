@@ -0,0 +1,38 @@
+package cleaner
+
+import (
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// verify leaves the volume untouched and only checks that it is empty. The
+// job fails when any file is left on a filesystem volume, or when the first
+// MiB of a block volume, where partition tables and filesystem signatures
+// live, contains anything but zeros.
+type verify struct{}
+
+func (c *verify) Name() string {
+	return Verify
+}
+
+func (c *verify) NewJob(config JobConfig) (*batchv1.Job, error) {
+	err := validateJobConfig(config, c.Name(), apiv1.PersistentVolumeFilesystem, apiv1.PersistentVolumeBlock)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if volumeMode(config.Claim) == apiv1.PersistentVolumeBlock {
+		script := "test -b /dev/scrub && test -z \"$(head -c 1048576 /dev/scrub | tr -d '\\000')\" || exit 1"
+
+		return newJob(config, c.Name(), script), nil
+	}
+
+	script := "test -e /scrub && test -z \"$(ls -A /scrub)\" || exit 1"
+
+	return newJob(config, c.Name(), script), nil
+}
+
+func (c *verify) Result(job *batchv1.Job) Result {
+	return jobResult(job)
+}
@@ -0,0 +1,35 @@
+package cleaner
+
+import (
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// wipeHeaders overwrites the first and the last MiB of a block volume with
+// zeros. That removes partition tables, including the GPT backup at the end
+// of the device, and filesystem signatures, while the remaining blocks are
+// left untouched.
+type wipeHeaders struct{}
+
+func (c *wipeHeaders) Name() string {
+	return WipeHeaders
+}
+
+func (c *wipeHeaders) NewJob(config JobConfig) (*batchv1.Job, error) {
+	err := validateJobConfig(config, c.Name(), apiv1.PersistentVolumeBlock)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// Devices not larger than the header region are overwritten as a whole.
+	// The tail is addressed in sectors, so that it ends with the device even
+	// if its size is no multiple of 1 MiB.
+	script := "test -b /dev/scrub && size=$(blockdev --getsize64 /dev/scrub) && if [ \"$size\" -le 1048576 ]; then dd if=/dev/zero of=/dev/scrub bs=512 count=$(( size / 512 )) conv=fsync; else dd if=/dev/zero of=/dev/scrub bs=1M count=1 conv=fsync && dd if=/dev/zero of=/dev/scrub bs=512 count=2048 seek=$(( size / 512 - 2048 )) conv=fsync; fi || exit 1"
+
+	return newJob(config, c.Name(), script), nil
+}
+
+func (c *wipeHeaders) Result(job *batchv1.Job) Result {
+	return jobResult(job)
+}
@@ -0,0 +1,39 @@
+package cleaner
+
+import (
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// zeroFill overwrites the whole device of block volumes with zeros. On
+// filesystem volumes it removes all files and afterwards writes zeros into a
+// single file until the filesystem is full, so that no previously used block
+// keeps its old content. The fill file is removed at the end.
+type zeroFill struct{}
+
+func (c *zeroFill) Name() string {
+	return ZeroFill
+}
+
+func (c *zeroFill) NewJob(config JobConfig) (*batchv1.Job, error) {
+	err := validateJobConfig(config, c.Name(), apiv1.PersistentVolumeFilesystem, apiv1.PersistentVolumeBlock)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if volumeMode(config.Claim) == apiv1.PersistentVolumeBlock {
+		script := "test -b /dev/scrub && size=$(blockdev --getsize64 /dev/scrub) && head -c \"$size\" /dev/zero > /dev/scrub && sync || exit 1"
+
+		return newJob(config, c.Name(), script), nil
+	}
+
+	// dd exits non-zero once the filesystem is full, which is expected here.
+	script := "test -e /scrub && rm -rf /scrub/..?* /scrub/.[!.]* /scrub/* && { dd if=/dev/zero of=/scrub/.pv-cleaner-fill bs=1M; sync; rm -f /scrub/.pv-cleaner-fill; } && test -z \"$(ls -A /scrub)\" || exit 1"
+
+	return newJob(config, c.Name(), script), nil
+}
+
+func (c *zeroFill) Result(job *batchv1.Job) Result {
+	return jobResult(job)
+}
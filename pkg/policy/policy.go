@@ -0,0 +1,227 @@
+// Package policy provides the cleanup policy which maps storage classes to
+// the way their released volumes are scrubbed.
+package policy
+
+import (
+	"io/ioutil"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+)
+
+const (
+	// defaultStorageClass is the storage class of volumes which do not
+	// name any.
+	defaultStorageClass = "default"
+	// storageClassAnnotation is the deprecated annotation naming the storage
+	// class of a volume, which takes precedence over the storage class name
+	// of the volume spec.
+	storageClassAnnotation = "volume.beta.kubernetes.io/storage-class"
+)
+
+// Policy maps storage class names to cleanup settings. Storage classes
+// without an entry, as well as settings a storage class entry leaves empty,
+// fall back to Default.
+//
+//	concurrency:
+//	  max: 20
+//	  maxPerNode: 2
+//	default:
+//	  strategy: delete-files
+//	storageClasses:
+//	  local-storage:
+//	    strategy: secure-wipe
+//	    blockStrategy: discard
+//	    parameters:
+//	      passes: "3"
+//	    image: quay.io/giantswarm/busybox:1.31.1
+//	    timeout: 1h
+//	    retries: 5
+//	    maxConcurrent: 5
+//	    gracePeriod: 30m
+//	    deadlines:
+//	      Cleaning: 2h
+//	      Teardown: 10m
+//	    resources:
+//	      requests:
+//	        cpu: 100m
+//	    pod:
+//	      nodeSelector:
+//	        node.giantswarm.io/storage: "true"
+//	      tolerations:
+//	      - key: node.giantswarm.io/storage
+//	        operator: Exists
+//
+// Rules, which are set at runtime from the cleanup policy custom resources,
+// take precedence over the storage classes.
+type Policy struct {
+	Concurrency    Concurrency      `json:"concurrency"`
+	Default        Class            `json:"default"`
+	StorageClasses map[string]Class `json:"storageClasses"`
+
+	mutex sync.RWMutex
+	rules []Rule
+}
+
+// Concurrency limits the number of volumes being cleaned at the same time.
+// Released volumes exceeding a limit are queued. Zero means unlimited.
+type Concurrency struct {
+	// Max is the number of volumes cleaned at the same time.
+	Max int `json:"max"`
+	// MaxPerNode is the number of node-local volumes of the same node cleaned
+	// at the same time.
+	MaxPerNode int `json:"maxPerNode"`
+}
+
+// Class describes how released volumes of a storage class are scrubbed.
+type Class struct {
+	// BlockStrategy is the name of the scrub strategy used for volumes with
+	// volume mode Block.
+	BlockStrategy string `json:"blockStrategy"`
+	// Deadlines are the times a volume may stay in a recycle state, keyed by
+	// the name of the state. Volumes exceeding a deadline get their cleanup
+	// claim or job recreated, or are marked as failed once all retries are
+	// used up. States without deadline are not limited.
+	Deadlines map[string]metav1.Duration `json:"deadlines"`
+	// GracePeriod is the time a released volume is kept untouched before it
+	// gets scrubbed.
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+	// Image is the container image running the cleanup job.
+	Image string `json:"image"`
+	// MaxConcurrent is the number of volumes of the storage class, or of the
+	// cleanup policy, cleaned at the same time. Zero means unlimited.
+	MaxConcurrent *int `json:"maxConcurrent,omitempty"`
+	// Parameters are the parameters of the scrub strategy.
+	Parameters map[string]string `json:"parameters"`
+	// Pod configures scheduling and security settings of the cleanup pod.
+	// Settings a storage class leaves empty are taken from the default.
+	Pod cleaner.PodConfig `json:"pod"`
+	// Retries is the number of times a failed cleanup job is recreated before
+	// the volume is marked as failed.
+	Retries *int `json:"retries,omitempty"`
+	// Resources are the compute resources of the cleanup job container.
+	Resources apiv1.ResourceRequirements `json:"resources"`
+	// Strategy is the name of the scrub strategy used for volumes with volume
+	// mode Filesystem.
+	Strategy string `json:"strategy"`
+	// Timeout is the time the cleanup job may run before it is considered
+	// failed.
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+// Load reads the policy from the YAML file at the given path.
+func Load(path string) (*Policy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var p Policy
+	err = yaml.UnmarshalStrict(b, &p)
+	if err != nil {
+		return nil, microerror.Maskf(invalidPolicyError, "%s: %s", path, err)
+	}
+
+	return &p, nil
+}
+
+// ForStorageClass returns the cleanup settings for the given storage class.
+func (p *Policy) ForStorageClass(storageClass string) Class {
+	c, ok := p.StorageClasses[storageClass]
+	if !ok {
+		return p.Default
+	}
+
+	return c.merge(p.Default)
+}
+
+// merge returns the cleanup settings with every empty setting taken from the
+// given defaults.
+func (c Class) merge(defaults Class) Class {
+	if c.BlockStrategy == "" {
+		c.BlockStrategy = defaults.BlockStrategy
+	}
+	if c.Deadlines == nil {
+		c.Deadlines = defaults.Deadlines
+	}
+	if c.GracePeriod == nil {
+		c.GracePeriod = defaults.GracePeriod
+	}
+	if c.Image == "" {
+		c.Image = defaults.Image
+	}
+	if c.MaxConcurrent == nil {
+		c.MaxConcurrent = defaults.MaxConcurrent
+	}
+	if c.Parameters == nil {
+		c.Parameters = defaults.Parameters
+	}
+	c.Pod = c.Pod.Merge(defaults.Pod)
+	if c.Retries == nil {
+		c.Retries = defaults.Retries
+	}
+	if c.Resources.Limits == nil && c.Resources.Requests == nil {
+		c.Resources = defaults.Resources
+	}
+	if c.Strategy == "" {
+		c.Strategy = defaults.Strategy
+	}
+	if c.Timeout.Duration == 0 {
+		c.Timeout = defaults.Timeout
+	}
+
+	return c
+}
+
+// Classes returns the default settings followed by the settings of every
+// configured storage class and of every rule.
+func (p *Policy) Classes() []Class {
+	classes := []Class{p.Default}
+	for name := range p.StorageClasses {
+		classes = append(classes, p.ForStorageClass(name))
+	}
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	for _, r := range p.rules {
+		classes = append(classes, r.Class.merge(p.Default))
+	}
+
+	return classes
+}
+
+// StorageClass returns the name of the storage class of the given volume.
+// Volumes without storage class belong to the storage class "default".
+func StorageClass(pv *apiv1.PersistentVolume) string {
+	name, ok := pv.Annotations[storageClassAnnotation]
+	if !ok {
+		if pv.Spec.StorageClassName != "" {
+			name = pv.Spec.StorageClassName
+		} else {
+			name = defaultStorageClass
+		}
+	}
+
+	return name
+}
+
+// ClaimStorageClass returns the name of the storage class the given claim
+// requests. Claims without storage class request the storage class
+// "default".
+func ClaimStorageClass(pvc *apiv1.PersistentVolumeClaim) string {
+	name, ok := pvc.Annotations[storageClassAnnotation]
+	if !ok {
+		if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName != "" {
+			name = *pvc.Spec.StorageClassName
+		} else {
+			name = defaultStorageClass
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Policy_ForStorageClass(t *testing.T) {
+	unlimited := 0
+	maxConcurrent := 5
+	p := &Policy{
+		Default: Class{
+			BlockStrategy: "wipe-headers",
+			Deadlines: map[string]metav1.Duration{
+				"Cleaning": {Duration: 2 * time.Hour},
+			},
+			GracePeriod:   &metav1.Duration{Duration: time.Hour},
+			Image:         "busybox",
+			MaxConcurrent: &maxConcurrent,
+			Strategy:      "delete-files",
+			Resources: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{
+					apiv1.ResourceCPU: resource.MustParse("100m"),
+				},
+			},
+		},
+		StorageClasses: map[string]Class{
+			"local-storage": {
+				GracePeriod: &metav1.Duration{Duration: 30 * time.Minute},
+				Strategy:    "zero-fill",
+				Timeout:     metav1.Duration{Duration: time.Hour},
+			},
+			"scratch": {
+				GracePeriod:   &metav1.Duration{},
+				MaxConcurrent: &unlimited,
+			},
+		},
+	}
+
+	testCases := []struct {
+		description   string
+		storageClass  string
+		expectedClass Class
+	}{
+		{
+			description:   "unknown storage class uses default settings",
+			storageClass:  "standard",
+			expectedClass: p.Default,
+		},
+		{
+			description:  "known storage class inherits settings it leaves empty",
+			storageClass: "local-storage",
+			expectedClass: Class{
+				BlockStrategy: "wipe-headers",
+				Deadlines:     p.Default.Deadlines,
+				GracePeriod:   &metav1.Duration{Duration: 30 * time.Minute},
+				Image:         "busybox",
+				MaxConcurrent: &maxConcurrent,
+				Resources:     p.Default.Resources,
+				Strategy:      "zero-fill",
+				Timeout:       metav1.Duration{Duration: time.Hour},
+			},
+		},
+		{
+			description:  "known storage class overrides settings with zero",
+			storageClass: "scratch",
+			expectedClass: Class{
+				BlockStrategy: "wipe-headers",
+				Deadlines:     p.Default.Deadlines,
+				GracePeriod:   &metav1.Duration{},
+				Image:         "busybox",
+				MaxConcurrent: &unlimited,
+				Resources:     p.Default.Resources,
+				Strategy:      "delete-files",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			result := p.ForStorageClass(tc.storageClass)
+			if !reflect.DeepEqual(tc.expectedClass, result) {
+				t.Fatalf("case %d expected %#v got %#v", i+1, tc.expectedClass, result)
+			}
+		})
+	}
+}
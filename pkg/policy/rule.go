@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// VolumeLabel marks persistent volumes which are cleaned by the operator
+	// once they are released, whether or not a rule selects them.
+	VolumeLabel = "persistentvolume.giantswarm.io/cleanup-on-release"
+	// VolumeLabelValue is the value of VolumeLabel.
+	VolumeLabelValue = "true"
+)
+
+// Rule selects persistent volumes and defines how they are scrubbed.
+type Rule struct {
+	// Class is the cleanup settings of the selected volumes. Settings the
+	// rule leaves empty are taken from the default of the policy.
+	Class Class
+	// Name identifies the rule. It is recorded on the volumes the rule
+	// selects.
+	Name string
+	// Priority orders the rules. Rules with higher priority are evaluated
+	// first, rules with equal priority by name.
+	Priority int
+	// Selector selects the volumes the rule applies to.
+	Selector Selector
+}
+
+// Selector selects persistent volumes. Empty fields match every volume, a
+// volume must match all fields to be selected.
+type Selector struct {
+	// ClaimNamespaces are the namespaces of the claim the volume is bound
+	// to, or was bound to before it got released.
+	ClaimNamespaces []string
+	// StorageClasses are the storage classes of the volume.
+	StorageClasses []string
+	// VolumeLabels selects the labels of the volume.
+	VolumeLabels labels.Selector
+	// VolumeSources are the types of the volume source, e.g. csi, hostPath
+	// or local.
+	VolumeSources []string
+}
+
+// Matches returns true if the selector selects the given volume.
+func (s Selector) Matches(pv *apiv1.PersistentVolume) bool {
+	if s.VolumeLabels != nil && !s.VolumeLabels.Matches(labels.Set(pv.Labels)) {
+		return false
+	}
+	if len(s.StorageClasses) > 0 && !contains(s.StorageClasses, StorageClass(pv)) {
+		return false
+	}
+	if len(s.VolumeSources) > 0 && !contains(s.VolumeSources, VolumeSource(pv)) {
+		return false
+	}
+	if len(s.ClaimNamespaces) > 0 {
+		if pv.Spec.ClaimRef == nil || !contains(s.ClaimNamespaces, pv.Spec.ClaimRef.Namespace) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetRules replaces the rules of the policy.
+func (p *Policy) SetRules(rules []Rule) {
+	sorted := append([]Rule(nil), rules...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.rules = sorted
+}
+
+// Match returns the first rule selecting the given volume, in priority
+// order. The class of the returned rule is merged with the default of the
+// policy. The second return value is false if no rule selects the volume.
+func (p *Policy) Match(pv *apiv1.PersistentVolume) (Rule, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for _, r := range p.rules {
+		if r.Selector.Matches(pv) {
+			r.Class = r.Class.merge(p.Default)
+			return r, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// ForRule returns the cleanup settings of the rule with the given name. The
+// second return value is false if the policy has no such rule.
+func (p *Policy) ForRule(name string) (Class, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for _, r := range p.rules {
+		if r.Name == name {
+			return r.Class.merge(p.Default), true
+		}
+	}
+
+	return Class{}, false
+}
+
+// VolumeSource returns the type of the source of the given volume, named
+// like its field in the persistent volume spec, e.g. csi, hostPath or local.
+func VolumeSource(pv *apiv1.PersistentVolume) string {
+	v := reflect.ValueOf(pv.Spec.PersistentVolumeSource)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsNil() {
+			continue
+		}
+
+		tag := v.Type().Field(i).Tag.Get("json")
+		return strings.Split(tag, ",")[0]
+	}
+
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
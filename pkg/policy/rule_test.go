@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func Test_Policy_Match(t *testing.T) {
+	p := &Policy{
+		Default: Class{
+			Strategy: "delete-files",
+		},
+	}
+	p.SetRules([]Rule{
+		{
+			Name:     "b-standard",
+			Priority: 10,
+			Selector: Selector{
+				StorageClasses: []string{"standard"},
+			},
+		},
+		{
+			Name:     "a-standard",
+			Priority: 10,
+			Selector: Selector{
+				StorageClasses: []string{"standard"},
+			},
+		},
+		{
+			Class: Class{
+				Strategy: "secure-wipe",
+			},
+			Name:     "tenant",
+			Priority: 100,
+			Selector: Selector{
+				ClaimNamespaces: []string{"tenant"},
+				VolumeLabels:    labels.SelectorFromSet(map[string]string{"sensitive": "true"}),
+				VolumeSources:   []string{"local"},
+			},
+		},
+	})
+
+	local := apiv1.PersistentVolumeSource{
+		Local: &apiv1.LocalVolumeSource{Path: "/mnt/disks/ssd1"},
+	}
+
+	testCases := []struct {
+		description      string
+		pv               *apiv1.PersistentVolume
+		expectedRule     string
+		expectedStrategy string
+	}{
+		{
+			description: "volume matching every selector field uses rule with highest priority",
+			pv: &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"sensitive": "true"},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					ClaimRef:               &apiv1.ObjectReference{Namespace: "tenant"},
+					PersistentVolumeSource: local,
+					StorageClassName:       "standard",
+				},
+			},
+			expectedRule:     "tenant",
+			expectedStrategy: "secure-wipe",
+		},
+		{
+			description: "volume without claim does not match claim namespaces",
+			pv: &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"sensitive": "true"},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					PersistentVolumeSource: local,
+					StorageClassName:       "standard",
+				},
+			},
+			expectedRule:     "a-standard",
+			expectedStrategy: "delete-files",
+		},
+		{
+			description: "volume of other storage class matches no rule",
+			pv: &apiv1.PersistentVolume{
+				Spec: apiv1.PersistentVolumeSpec{
+					ClaimRef:               &apiv1.ObjectReference{Namespace: "tenant"},
+					PersistentVolumeSource: local,
+					StorageClassName:       "fast",
+				},
+			},
+			expectedRule: "",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rule, ok := p.Match(tc.pv)
+			if ok != (tc.expectedRule != "") {
+				t.Fatalf("case %d expected match %t got %t", i+1, tc.expectedRule != "", ok)
+			}
+			if rule.Name != tc.expectedRule {
+				t.Fatalf("case %d expected rule %#q got %#q", i+1, tc.expectedRule, rule.Name)
+			}
+			if rule.Class.Strategy != tc.expectedStrategy {
+				t.Fatalf("case %d expected strategy %#q got %#q", i+1, tc.expectedStrategy, rule.Class.Strategy)
+			}
+		})
+	}
+}
+
+func Test_VolumeSource(t *testing.T) {
+	testCases := []struct {
+		description    string
+		source         apiv1.PersistentVolumeSource
+		expectedSource string
+	}{
+		{
+			description:    "local volume",
+			source:         apiv1.PersistentVolumeSource{Local: &apiv1.LocalVolumeSource{}},
+			expectedSource: "local",
+		},
+		{
+			description:    "host path volume",
+			source:         apiv1.PersistentVolumeSource{HostPath: &apiv1.HostPathVolumeSource{}},
+			expectedSource: "hostPath",
+		},
+		{
+			description:    "volume without source",
+			source:         apiv1.PersistentVolumeSource{},
+			expectedSource: "",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				Spec: apiv1.PersistentVolumeSpec{
+					PersistentVolumeSource: tc.source,
+				},
+			}
+
+			source := VolumeSource(pv)
+			if source != tc.expectedSource {
+				t.Fatalf("case %d expected %#q got %#q", i+1, tc.expectedSource, source)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package recycle
+
+import (
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// CommandAnnotation is the persistent volume annotation holding a command
+	// requested by an administrator, which the operator applies during the
+	// next reconciliation.
+	CommandAnnotation = "pv-cleaner-operator.giantswarm.io/command"
+	// CommandRequestedByAnnotation is the persistent volume annotation holding
+	// the name of the user who requested the command. It is not verified,
+	// anyone allowed to update the volume can set it.
+	CommandRequestedByAnnotation = "pv-cleaner-operator.giantswarm.io/command-requested-by"
+)
+
+// Command is a manual intervention on the recycling of a persistent volume.
+type Command string
+
+const (
+	// Recycle queues the volume for cleaning right away, skipping the rest
+	// of its grace period, or starts over the cleanup of a failed volume.
+	Recycle Command = "recycle"
+	// Retry resumes the cleanup of a failed volume with a fresh retry count,
+	// without waiting for a free cleanup slot.
+	Retry Command = "retry"
+	// Skip marks the volume as recycled without scrubbing it.
+	Skip Command = "skip"
+)
+
+// condition is a combination of volume phase and recycle state.
+type condition struct {
+	phase apiv1.PersistentVolumePhase
+	state State
+}
+
+// commands lists the phases and recycle states each command may be applied
+// in. Volumes being cleaned or torn down are never interrupted.
+var commands = map[Command][]condition{
+	Recycle: {
+		{phase: apiv1.VolumeAvailable, state: Recycled},
+		{phase: apiv1.VolumeAvailable, state: Failed},
+		{phase: apiv1.VolumeReleased, state: Recycled},
+		{phase: apiv1.VolumeReleased, state: Failed},
+	},
+	Retry: {
+		{phase: apiv1.VolumeAvailable, state: Failed},
+		{phase: apiv1.VolumeReleased, state: Failed},
+	},
+	Skip: {
+		{phase: apiv1.VolumeAvailable, state: Queued},
+		{phase: apiv1.VolumeAvailable, state: Failed},
+		{phase: apiv1.VolumeReleased, state: Recycled},
+		{phase: apiv1.VolumeReleased, state: Queued},
+		{phase: apiv1.VolumeReleased, state: Failed},
+	},
+}
+
+// stuckCommands lists the commands which may be applied to available and
+// released volumes in a recycle state without transition, e.g. one set by
+// hand, so that they can be recycled again.
+var stuckCommands = []Command{Recycle, Skip}
+
+// CheckCommand checks whether the given command may be applied to a volume in
+// the given phase and recycle state. An empty recycle state is treated as
+// Recycled. Unknown commands result in an unknownCommandError, commands which
+// are not allowed in a commandNotAllowedError.
+func CheckCommand(phase apiv1.PersistentVolumePhase, state State, command Command) error {
+	if state == "" {
+		state = Recycled
+	}
+
+	allowed, ok := commands[command]
+	if !ok {
+		return microerror.Maskf(unknownCommandError, "%#q", command)
+	}
+
+	for _, t := range allowed {
+		if t.phase == phase && t.state == state {
+			return nil
+		}
+	}
+
+	_, err := NextTransition(phase, state)
+	if IsUnknownState(err) && (phase == apiv1.VolumeAvailable || phase == apiv1.VolumeReleased) {
+		for _, c := range stuckCommands {
+			if c == command {
+				return nil
+			}
+		}
+	}
+
+	return microerror.Maskf(commandNotAllowedError, "command %#q in phase %#q with recycle state %#q", command, phase, state)
+}
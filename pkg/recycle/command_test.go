@@ -0,0 +1,107 @@
+package recycle
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func Test_Recycle_CheckCommand(t *testing.T) {
+	testCases := []struct {
+		description  string
+		phase        apiv1.PersistentVolumePhase
+		state        State
+		command      Command
+		errorMatcher func(error) bool
+	}{
+		{
+			description: "released volume can be recycled",
+			phase:       apiv1.VolumeReleased,
+			state:       "",
+			command:     Recycle,
+		},
+		{
+			description: "failed volume can be retried",
+			phase:       apiv1.VolumeReleased,
+			state:       Failed,
+			command:     Retry,
+		},
+		{
+			description: "queued volume can be skipped",
+			phase:       apiv1.VolumeReleased,
+			state:       Queued,
+			command:     Skip,
+		},
+		{
+			description:  "volume being cleaned can not be skipped",
+			phase:        apiv1.VolumeBound,
+			state:        Cleaning,
+			command:      Skip,
+			errorMatcher: IsCommandNotAllowed,
+		},
+		{
+			description:  "volume in use can not be recycled",
+			phase:        apiv1.VolumeBound,
+			state:        Recycled,
+			command:      Recycle,
+			errorMatcher: IsCommandNotAllowed,
+		},
+		{
+			description:  "volume which did not fail can not be retried",
+			phase:        apiv1.VolumeReleased,
+			state:        Queued,
+			command:      Retry,
+			errorMatcher: IsCommandNotAllowed,
+		},
+		{
+			description: "released volume in unknown recycle state can be skipped",
+			phase:       apiv1.VolumeReleased,
+			state:       "Scrubbing",
+			command:     Skip,
+		},
+		{
+			description: "available volume in unknown recycle state can be recycled",
+			phase:       apiv1.VolumeAvailable,
+			state:       "Scrubbing",
+			command:     Recycle,
+		},
+		{
+			description:  "released volume in unknown recycle state can not be retried",
+			phase:        apiv1.VolumeReleased,
+			state:        "Scrubbing",
+			command:      Retry,
+			errorMatcher: IsCommandNotAllowed,
+		},
+		{
+			description:  "bound volume in unknown recycle state can not be skipped",
+			phase:        apiv1.VolumeBound,
+			state:        "Scrubbing",
+			command:      Skip,
+			errorMatcher: IsCommandNotAllowed,
+		},
+		{
+			description:  "unknown command is rejected",
+			phase:        apiv1.VolumeReleased,
+			state:        Failed,
+			command:      "wipe",
+			errorMatcher: IsUnknownCommand,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := CheckCommand(tc.phase, tc.state, tc.command)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("case %d unexpected error: %#v", i+1, err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("case %d expected error got nil", i+1)
+			case !tc.errorMatcher(err):
+				t.Fatalf("case %d unexpected error: %#v", i+1, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package recycle
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var unknownStateError = &microerror.Error{
+	Kind: "unknownStateError",
+}
+
+// IsUnknownState asserts unknownStateError.
+func IsUnknownState(err error) bool {
+	return microerror.Cause(err) == unknownStateError
+}
+
+var commandNotAllowedError = &microerror.Error{
+	Kind: "commandNotAllowedError",
+}
+
+// IsCommandNotAllowed asserts commandNotAllowedError.
+func IsCommandNotAllowed(err error) bool {
+	return microerror.Cause(err) == commandNotAllowedError
+}
+
+var unknownCommandError = &microerror.Error{
+	Kind: "unknownCommandError",
+}
+
+// IsUnknownCommand asserts unknownCommandError.
+func IsUnknownCommand(err error) bool {
+	return microerror.Cause(err) == unknownCommandError
+}
@@ -0,0 +1,171 @@
+// Package recycle defines the recycle states of persistent volumes and the
+// transitions the operator performs between them.
+package recycle
+
+import (
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// StateAnnotation is the persistent volume annotation holding the recycle
+	// state.
+	StateAnnotation = "pv-cleaner-operator.giantswarm.io/volume-recycle-state"
+	// StateChangedAtAnnotation is the persistent volume annotation holding the
+	// time of the last recycle state transition in RFC 3339 format.
+	StateChangedAtAnnotation = "pv-cleaner-operator.giantswarm.io/state-changed-at"
+	// FailureReasonAnnotation is the persistent volume annotation holding the
+	// reason of the last failure.
+	FailureReasonAnnotation = "pv-cleaner-operator.giantswarm.io/failure-reason"
+	// FailureMessageAnnotation is the persistent volume annotation holding the
+	// message of the last failure.
+	FailureMessageAnnotation = "pv-cleaner-operator.giantswarm.io/failure-message"
+	// HoldAnnotation is the persistent volume annotation which keeps released
+	// volumes from being cleaned while it is set to "true". Volumes already
+	// being cleaned are not affected.
+	HoldAnnotation = "pv-cleaner-operator.giantswarm.io/hold"
+	// CleanupAfterAnnotation is the persistent volume annotation holding the
+	// time the grace period of a released volume ends and its cleaning starts,
+	// in RFC 3339 format. It is not set while the volume is held.
+	CleanupAfterAnnotation = "pv-cleaner-operator.giantswarm.io/cleanup-after"
+	// RestoreAnnotation is the persistent volume claim annotation which opts
+	// the claim in to be bound to the released volume of the deleted claim
+	// with the same namespace and name, while the volume is within its grace
+	// period or held, when set to "true".
+	RestoreAnnotation = "pv-cleaner-operator.giantswarm.io/restore-released-volume"
+	// PreviousClaimAnnotation is the persistent volume annotation holding the
+	// namespace and name of the claim a released volume was bound to, as
+	// namespace/name pair.
+	PreviousClaimAnnotation = "pv-cleaner-operator.giantswarm.io/previous-claim"
+)
+
+// State is the recycle state of a persistent volume.
+type State string
+
+const (
+	// Cleaning volumes are bound to a cleanup claim and scrubbed by a cleanup
+	// job.
+	Cleaning State = "Cleaning"
+	// Failed volumes could not be recycled. Failed is terminal, volumes stay
+	// failed until an administrator requests a command for them.
+	Failed State = "Failed"
+	// Queued volumes were released and wait for a free cleanup slot. They
+	// keep their claim reference, so that they can not be bound while they
+	// are queued.
+	Queued State = "Queued"
+	// Recycled volumes were scrubbed, or were never released since the
+	// operator started managing them. Volumes without recycle state
+	// annotation are considered recycled.
+	Recycled State = "Recycled"
+	// Teardown volumes were scrubbed and wait for their cleanup claim to be
+	// removed.
+	Teardown State = "Teardown"
+)
+
+// Action is the work the operator does for a persistent volume.
+type Action string
+
+const (
+	// Admit starts cleaning the queued volume once the concurrency limits
+	// allow it.
+	Admit Action = "Admit"
+	// CreateClaim creates the cleanup claim binding the volume.
+	CreateClaim Action = "CreateClaim"
+	// Fail marks the volume as failed.
+	Fail Action = "Fail"
+	// FinishRecycle marks the volume as recycled.
+	FinishRecycle Action = "FinishRecycle"
+	// None means there is nothing to do, either because the volume reached
+	// its desired state, or because the operator waits for Kubernetes to act.
+	None Action = "None"
+	// RunJob runs the cleanup job and removes the cleanup claim once the job
+	// succeeded.
+	RunJob Action = "RunJob"
+	// StartCleaning queues released volumes once their grace period passed,
+	// and releases the claim reference of volumes which lost their cleanup
+	// claim so that they can be bound by a new cleanup claim.
+	StartCleaning Action = "StartCleaning"
+)
+
+// Transition describes what happens to a volume in a given phase and
+// recycle state.
+type Transition struct {
+	Phase apiv1.PersistentVolumePhase
+	State State
+	// Action is the work done for the volume.
+	Action Action
+	// Next is the recycle state the volume is in once Action is done.
+	Next State
+}
+
+// transitions lists every known combination of volume phase and recycle
+// state.
+var transitions = []Transition{
+	// Volumes which are not yet available are left alone.
+	{Phase: apiv1.VolumePending, State: Recycled, Action: None, Next: Recycled},
+	{Phase: apiv1.VolumePending, State: Queued, Action: None, Next: Queued},
+	{Phase: apiv1.VolumePending, State: Cleaning, Action: None, Next: Cleaning},
+	{Phase: apiv1.VolumePending, State: Teardown, Action: None, Next: Teardown},
+
+	// Available and recycled is the desired state.
+	{Phase: apiv1.VolumeAvailable, State: Recycled, Action: None, Next: Recycled},
+	// The claim reference of the queued volume was removed manually.
+	{Phase: apiv1.VolumeAvailable, State: Queued, Action: Admit, Next: Cleaning},
+	// The volume waits to be bound by a cleanup claim.
+	{Phase: apiv1.VolumeAvailable, State: Cleaning, Action: CreateClaim, Next: Cleaning},
+	// The cleanup claim was removed before the volume got released.
+	{Phase: apiv1.VolumeAvailable, State: Teardown, Action: FinishRecycle, Next: Recycled},
+
+	// The volume is in use by a workload.
+	{Phase: apiv1.VolumeBound, State: Recycled, Action: None, Next: Recycled},
+	// The queued volume was bound again manually.
+	{Phase: apiv1.VolumeBound, State: Queued, Action: None, Next: Queued},
+	// The volume is bound to the cleanup claim and gets scrubbed.
+	{Phase: apiv1.VolumeBound, State: Cleaning, Action: RunJob, Next: Teardown},
+	// The cleanup claim is being removed.
+	{Phase: apiv1.VolumeBound, State: Teardown, Action: None, Next: Teardown},
+
+	// The claim of a workload was deleted.
+	{Phase: apiv1.VolumeReleased, State: Recycled, Action: StartCleaning, Next: Queued},
+	// The volume waits for a free cleanup slot.
+	{Phase: apiv1.VolumeReleased, State: Queued, Action: Admit, Next: Cleaning},
+	// The cleanup claim was removed before the volume was scrubbed.
+	{Phase: apiv1.VolumeReleased, State: Cleaning, Action: StartCleaning, Next: Cleaning},
+	// The cleanup claim was removed after the volume was scrubbed.
+	{Phase: apiv1.VolumeReleased, State: Teardown, Action: FinishRecycle, Next: Recycled},
+
+	// Kubernetes failed to reclaim the volume.
+	{Phase: apiv1.VolumeFailed, State: Recycled, Action: Fail, Next: Failed},
+	{Phase: apiv1.VolumeFailed, State: Queued, Action: Fail, Next: Failed},
+	{Phase: apiv1.VolumeFailed, State: Cleaning, Action: Fail, Next: Failed},
+	{Phase: apiv1.VolumeFailed, State: Teardown, Action: Fail, Next: Failed},
+
+	// Failed volumes need manual intervention.
+	{Phase: apiv1.VolumePending, State: Failed, Action: None, Next: Failed},
+	{Phase: apiv1.VolumeAvailable, State: Failed, Action: None, Next: Failed},
+	{Phase: apiv1.VolumeBound, State: Failed, Action: None, Next: Failed},
+	{Phase: apiv1.VolumeReleased, State: Failed, Action: None, Next: Failed},
+	{Phase: apiv1.VolumeFailed, State: Failed, Action: None, Next: Failed},
+}
+
+// NextTransition returns the transition for a volume in the given phase and
+// recycle state. An empty recycle state is treated as Recycled. Combinations
+// which are not known result in an unknownStateError.
+func NextTransition(phase apiv1.PersistentVolumePhase, state State) (Transition, error) {
+	if state == "" {
+		state = Recycled
+	}
+
+	for _, t := range transitions {
+		if t.Phase == phase && t.State == state {
+			return t, nil
+		}
+	}
+
+	return Transition{}, microerror.Maskf(unknownStateError, "phase %#q with recycle state %#q", phase, state)
+}
+
+// Transitions returns all known transitions.
+func Transitions() []Transition {
+	return append([]Transition(nil), transitions...)
+}
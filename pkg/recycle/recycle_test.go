@@ -0,0 +1,97 @@
+package recycle
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func Test_Recycle_NextTransition(t *testing.T) {
+	testCases := []struct {
+		description        string
+		phase              apiv1.PersistentVolumePhase
+		state              State
+		expectedTransition Transition
+		errorMatcher       func(error) bool
+	}{
+		{
+			description:        "released volume without recycle state is queued",
+			phase:              apiv1.VolumeReleased,
+			state:              "",
+			expectedTransition: Transition{Phase: apiv1.VolumeReleased, State: Recycled, Action: StartCleaning, Next: Queued},
+		},
+		{
+			description:        "released queued volume is admitted",
+			phase:              apiv1.VolumeReleased,
+			state:              Queued,
+			expectedTransition: Transition{Phase: apiv1.VolumeReleased, State: Queued, Action: Admit, Next: Cleaning},
+		},
+		{
+			description:        "bound cleaning volume runs cleanup job",
+			phase:              apiv1.VolumeBound,
+			state:              Cleaning,
+			expectedTransition: Transition{Phase: apiv1.VolumeBound, State: Cleaning, Action: RunJob, Next: Teardown},
+		},
+		{
+			description:        "failed cleaning volume is marked as failed",
+			phase:              apiv1.VolumeFailed,
+			state:              Cleaning,
+			expectedTransition: Transition{Phase: apiv1.VolumeFailed, State: Cleaning, Action: Fail, Next: Failed},
+		},
+		{
+			description:        "pending cleaning volume waits",
+			phase:              apiv1.VolumePending,
+			state:              Cleaning,
+			expectedTransition: Transition{Phase: apiv1.VolumePending, State: Cleaning, Action: None, Next: Cleaning},
+		},
+		{
+			description:        "failed recycle state is terminal",
+			phase:              apiv1.VolumeReleased,
+			state:              Failed,
+			expectedTransition: Transition{Phase: apiv1.VolumeReleased, State: Failed, Action: None, Next: Failed},
+		},
+		{
+			description:  "unknown recycle state returns error",
+			phase:        apiv1.VolumeReleased,
+			state:        "Scrubbing",
+			errorMatcher: IsUnknownState,
+		},
+		{
+			description:  "unknown phase returns error",
+			phase:        "Lost",
+			state:        Recycled,
+			errorMatcher: IsUnknownState,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			result, err := NextTransition(tc.phase, tc.state)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("case %d expected error matcher to match, got %#v", i+1, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting transition: %s\n", i+1, err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedTransition, result) {
+				t.Fatalf("case %d expected %#v got %#v", i+1, tc.expectedTransition, result)
+			}
+		})
+	}
+}
+
+func Test_Recycle_Transitions_Unique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, tr := range Transitions() {
+		k := string(tr.Phase) + string(tr.State)
+		if seen[k] {
+			t.Fatalf("expected one transition for phase %#q and recycle state %#q, got several", tr.Phase, tr.State)
+		}
+		seen[k] = true
+	}
+}
@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+	"github.com/giantswarm/pv-cleaner-operator/service/admin"
+)
+
+const (
+	// Method is the HTTP method this endpoint is registered for.
+	Method = "POST"
+	// Name identifies the endpoint. It is aligned to the package path.
+	Name = "admin"
+	// Path is the HTTP request path this endpoint is registered for.
+	Path = "/admin/volumes/{volume}/{command}"
+)
+
+const (
+	bearerPrefix = "Bearer "
+)
+
+// Config represents the configuration used to create an admin endpoint.
+type Config struct {
+	// Dependencies.
+	Logger  micrologger.Logger
+	Service *admin.Service
+}
+
+// New creates a new configured admin endpoint.
+func New(config Config) (*Endpoint, error) {
+	// Dependencies.
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+	if config.Service == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Service must not be empty")
+	}
+
+	newEndpoint := &Endpoint{
+		Config: config,
+	}
+
+	return newEndpoint, nil
+}
+
+// Endpoint requests a command for a persistent volume, e.g.
+// POST /admin/volumes/pvc-0a1b2c/retry. The command is one of recycle, retry
+// or skip. Callers authenticate with their Kubernetes bearer token in the
+// Authorization header.
+type Endpoint struct {
+	Config
+}
+
+func (e *Endpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		vars := mux.Vars(r)
+
+		var token string
+		if header := r.Header.Get("Authorization"); strings.HasPrefix(header, bearerPrefix) {
+			token = strings.TrimPrefix(header, bearerPrefix)
+		}
+
+		request := admin.Request{
+			Command: recycle.Command(vars["command"]),
+			Token:   token,
+			Volume:  vars["volume"],
+		}
+
+		return request, nil
+	}
+}
+
+func (e *Endpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusAccepted)
+
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (e *Endpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := e.Service.Apply(ctx, request.(admin.Request))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return response, nil
+	}
+}
+
+func (e *Endpoint) Method() string {
+	return Method
+}
+
+func (e *Endpoint) Middlewares() []kitendpoint.Middleware {
+	return []kitendpoint.Middleware{}
+}
+
+func (e *Endpoint) Name() string {
+	return Name
+}
+
+func (e *Endpoint) Path() string {
+	return Path
+}
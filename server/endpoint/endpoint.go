@@ -5,6 +5,8 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 
+	"github.com/giantswarm/pv-cleaner-operator/server/endpoint/admin"
+	"github.com/giantswarm/pv-cleaner-operator/server/endpoint/volume"
 	"github.com/giantswarm/pv-cleaner-operator/service"
 )
 
@@ -30,13 +32,39 @@ func New(config Config) (*Endpoint, error) {
 		}
 	}
 
+	var adminEndpoint *admin.Endpoint
+	{
+		adminConfig := admin.Config{}
+		adminConfig.Logger = config.Logger
+		adminConfig.Service = config.Service.Admin
+		adminEndpoint, err = admin.New(adminConfig)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var volumeEndpoint *volume.Endpoint
+	{
+		volumeConfig := volume.Config{}
+		volumeConfig.Logger = config.Logger
+		volumeConfig.Service = config.Service.Volume
+		volumeEndpoint, err = volume.New(volumeConfig)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	newEndpoint := &Endpoint{
+		Admin:   adminEndpoint,
 		Version: versionEndpoint,
+		Volume:  volumeEndpoint,
 	}
 	return newEndpoint, nil
 }
 
 // Endpoint is the endpoint collection.
 type Endpoint struct {
+	Admin   *admin.Endpoint
 	Version *versionendpoint.Endpoint
+	Volume  *volume.Endpoint
 }
@@ -0,0 +1,111 @@
+package volume
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"github.com/giantswarm/pv-cleaner-operator/service/volume"
+)
+
+const (
+	// Method is the HTTP method this endpoint is registered for.
+	Method = "GET"
+	// Name identifies the endpoint. It is aligned to the package path.
+	Name = "volume"
+	// Path is the HTTP request path this endpoint is registered for.
+	Path = "/volumes"
+)
+
+const (
+	// recycleStateQuery is the query parameter filtering volumes by recycle
+	// state.
+	recycleStateQuery = "recycle_state"
+	// storageClassQuery is the query parameter filtering volumes by storage
+	// class.
+	storageClassQuery = "storage_class"
+)
+
+// Config represents the configuration used to create a volume endpoint.
+type Config struct {
+	// Dependencies.
+	Logger  micrologger.Logger
+	Service *volume.Service
+}
+
+// New creates a new configured volume endpoint.
+func New(config Config) (*Endpoint, error) {
+	// Dependencies.
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+	if config.Service == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Service must not be empty")
+	}
+
+	newEndpoint := &Endpoint{
+		Config: config,
+	}
+
+	return newEndpoint, nil
+}
+
+// Endpoint lists the persistent volumes managed by the operator, optionally
+// filtered by the recycle_state and storage_class query parameters, e.g.
+// /volumes?recycle_state=Failed&storage_class=local-storage.
+type Endpoint struct {
+	Config
+}
+
+func (e *Endpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		query := r.URL.Query()
+
+		request := volume.Request{
+			RecycleState: query.Get(recycleStateQuery),
+			StorageClass: query.Get(storageClassQuery),
+		}
+
+		return request, nil
+	}
+}
+
+func (e *Endpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (e *Endpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		responses, err := e.Service.Search(ctx, request.(volume.Request))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return responses, nil
+	}
+}
+
+func (e *Endpoint) Method() string {
+	return Method
+}
+
+func (e *Endpoint) Middlewares() []kitendpoint.Middleware {
+	return []kitendpoint.Middleware{}
+}
+
+func (e *Endpoint) Name() string {
+	return Name
+}
+
+func (e *Endpoint) Path() string {
+	return Path
+}
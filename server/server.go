@@ -11,9 +11,12 @@ import (
 	microserver "github.com/giantswarm/microkit/server"
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/errors"
 
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
 	"github.com/giantswarm/pv-cleaner-operator/server/endpoint"
 	"github.com/giantswarm/pv-cleaner-operator/service"
+	"github.com/giantswarm/pv-cleaner-operator/service/admin"
 )
 
 // Config represents the configuration used to create a new server object.
@@ -51,7 +54,9 @@ func New(config Config) (microserver.Server, error) {
 			Viper:       config.Viper,
 
 			Endpoints: []microserver.Endpoint{
+				endpointCollection.Admin,
 				endpointCollection.Version,
+				endpointCollection.Volume,
 			},
 			ErrorEncoder: errorEncoder,
 		},
@@ -94,7 +99,26 @@ func errorEncoder(ctx context.Context, err error, w http.ResponseWriter) {
 	rErr := err.(microserver.ResponseError)
 	uErr := rErr.Underlying()
 
-	rErr.SetCode(microserver.CodeInternalError)
 	rErr.SetMessage(uErr.Error())
-	w.WriteHeader(http.StatusInternalServerError)
+
+	switch {
+	case admin.IsUnauthenticated(uErr):
+		rErr.SetCode(microserver.CodeInvalidCredentials)
+		w.WriteHeader(http.StatusUnauthorized)
+	case admin.IsForbidden(uErr):
+		rErr.SetCode(microserver.CodePermissionDenied)
+		w.WriteHeader(http.StatusForbidden)
+	case admin.IsVolumeNotFound(uErr):
+		rErr.SetCode(microserver.CodeResourceNotFound)
+		w.WriteHeader(http.StatusNotFound)
+	case recycle.IsUnknownCommand(uErr):
+		rErr.SetCode(microserver.CodeInvalidInput)
+		w.WriteHeader(http.StatusBadRequest)
+	case recycle.IsCommandNotAllowed(uErr), errors.IsConflict(microerror.Cause(uErr)):
+		rErr.SetCode(microserver.CodeFailure)
+		w.WriteHeader(http.StatusConflict)
+	default:
+		rErr.SetCode(microserver.CodeInternalError)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
 }
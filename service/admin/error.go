@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var forbiddenError = &microerror.Error{
+	Kind: "forbiddenError",
+}
+
+// IsForbidden asserts forbiddenError.
+func IsForbidden(err error) bool {
+	return microerror.Cause(err) == forbiddenError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var unauthenticatedError = &microerror.Error{
+	Kind: "unauthenticatedError",
+}
+
+// IsUnauthenticated asserts unauthenticatedError.
+func IsUnauthenticated(err error) bool {
+	return microerror.Cause(err) == unauthenticatedError
+}
+
+var volumeNotFoundError = &microerror.Error{
+	Kind: "volumeNotFoundError",
+}
+
+// IsVolumeNotFound asserts volumeNotFoundError.
+func IsVolumeNotFound(err error) bool {
+	return microerror.Cause(err) == volumeNotFoundError
+}
@@ -0,0 +1,16 @@
+package admin
+
+import (
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+// Request is the command an administrator requests for a persistent volume.
+type Request struct {
+	// Command is the command to apply to the volume.
+	Command recycle.Command
+	// Token is the bearer token of the administrator, which is verified
+	// against the Kubernetes API.
+	Token string
+	// Volume is the name of the persistent volume.
+	Volume string
+}
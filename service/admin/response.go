@@ -0,0 +1,11 @@
+package admin
+
+// Response is the command accepted for a persistent volume. The operator
+// applies it during the next reconciliation of the volume.
+type Response struct {
+	Command      string `json:"command"`
+	Phase        string `json:"phase"`
+	RecycleState string `json:"recycle_state"`
+	RequestedBy  string `json:"requested_by"`
+	Volume       string `json:"volume"`
+}
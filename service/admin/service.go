@@ -0,0 +1,174 @@
+// Package admin lets administrators request commands for the persistent
+// volumes managed by the operator, e.g. to retry a failed cleanup. Callers
+// are authenticated with their Kubernetes bearer token and must be allowed to
+// update the persistent volume.
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+type Config struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// Handles returns true for the persistent volumes managed by the
+	// operator.
+	Handles func(pv *apiv1.PersistentVolume) bool
+}
+
+type Service struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+
+	handles func(pv *apiv1.PersistentVolume) bool
+}
+
+func New(config Config) (*Service, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+
+	if config.Handles == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Handles must not be empty")
+	}
+
+	s := &Service{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		handles: config.Handles,
+	}
+
+	return s, nil
+}
+
+// Apply authenticates and authorizes the caller, checks the command against
+// the current phase and recycle state of the volume and records it on the
+// volume, together with the name of the caller.
+func (s *Service) Apply(ctx context.Context, request Request) (Response, error) {
+	user, err := s.authenticate(request.Token)
+	if err != nil {
+		return Response{}, microerror.Mask(err)
+	}
+
+	err = s.authorize(user, request.Volume)
+	if err != nil {
+		s.logger.LogCtx(ctx, "level", "warning", "persistentvolume", request.Volume, "user", user.Username, "message", fmt.Sprintf("denied command %#q", request.Command))
+		return Response{}, microerror.Mask(err)
+	}
+
+	pv, err := s.k8sClient.CoreV1().PersistentVolumes().Get(request.Volume, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return Response{}, microerror.Maskf(volumeNotFoundError, "persistent volume %#q", request.Volume)
+	} else if err != nil {
+		return Response{}, microerror.Mask(err)
+	}
+	if !s.handles(pv) {
+		return Response{}, microerror.Maskf(volumeNotFoundError, "persistent volume %#q is not managed by the operator", request.Volume)
+	}
+
+	state := recycle.State(pv.Annotations[recycle.StateAnnotation])
+	if state == "" {
+		state = recycle.Recycled
+	}
+
+	err = recycle.CheckCommand(pv.Status.Phase, state, request.Command)
+	if err != nil {
+		return Response{}, microerror.Mask(err)
+	}
+
+	if pv.Annotations == nil {
+		pv.Annotations = map[string]string{}
+	}
+	pv.Annotations[recycle.CommandAnnotation] = string(request.Command)
+	pv.Annotations[recycle.CommandRequestedByAnnotation] = user.Username
+
+	_, err = s.k8sClient.CoreV1().PersistentVolumes().Update(pv)
+	if err != nil {
+		return Response{}, microerror.Mask(err)
+	}
+
+	s.logger.LogCtx(ctx, "level", "info", "persistentvolume", pv.Name, "user", user.Username, "message", fmt.Sprintf("accepted command %#q", request.Command))
+
+	response := Response{
+		Command:      string(request.Command),
+		Phase:        string(pv.Status.Phase),
+		RecycleState: string(state),
+		RequestedBy:  user.Username,
+		Volume:       pv.Name,
+	}
+
+	return response, nil
+}
+
+// authenticate returns the user the given bearer token belongs to.
+func (s *Service) authenticate(token string) (authenticationv1.UserInfo, error) {
+	if token == "" {
+		return authenticationv1.UserInfo{}, microerror.Maskf(unauthenticatedError, "bearer token must not be empty")
+	}
+
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token: token,
+		},
+	}
+
+	result, err := s.k8sClient.AuthenticationV1().TokenReviews().Create(review)
+	if err != nil {
+		return authenticationv1.UserInfo{}, microerror.Mask(err)
+	}
+	if !result.Status.Authenticated {
+		return authenticationv1.UserInfo{}, microerror.Maskf(unauthenticatedError, "%s", result.Status.Error)
+	}
+
+	return result.Status.User, nil
+}
+
+// authorize checks whether the given user is allowed to update the
+// persistent volume with the given name.
+func (s *Service) authorize(user authenticationv1.UserInfo, volume string) error {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			Extra:  extra,
+			Groups: user.Groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Name:     volume,
+				Resource: "persistentvolumes",
+				Verb:     "update",
+			},
+			UID:  user.UID,
+			User: user.Username,
+		},
+	}
+
+	result, err := s.k8sClient.AuthorizationV1().SubjectAccessReviews().Create(review)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if !result.Status.Allowed {
+		return microerror.Maskf(forbiddenError, "user %#q must be allowed to update persistent volume %#q", user.Username, volume)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package admin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Admin_Apply(t *testing.T) {
+	testCases := []struct {
+		description         string
+		request             Request
+		expectedRequestedBy string
+		errorMatcher        func(error) bool
+	}{
+		{
+			description:         "allowed user retries failed volume",
+			request:             Request{Command: recycle.Retry, Token: "admin-token", Volume: "failed"},
+			expectedRequestedBy: "admin@example.com",
+		},
+		{
+			description:  "request without token is rejected",
+			request:      Request{Command: recycle.Retry, Volume: "failed"},
+			errorMatcher: IsUnauthenticated,
+		},
+		{
+			description:  "request with invalid token is rejected",
+			request:      Request{Command: recycle.Retry, Token: "expired-token", Volume: "failed"},
+			errorMatcher: IsUnauthenticated,
+		},
+		{
+			description:  "user without permission is rejected",
+			request:      Request{Command: recycle.Retry, Token: "viewer-token", Volume: "failed"},
+			errorMatcher: IsForbidden,
+		},
+		{
+			description:  "missing volume is rejected",
+			request:      Request{Command: recycle.Retry, Token: "admin-token", Volume: "missing"},
+			errorMatcher: IsVolumeNotFound,
+		},
+		{
+			description:  "volume not managed by the operator is rejected",
+			request:      Request{Command: recycle.Retry, Token: "admin-token", Volume: "unmanaged"},
+			errorMatcher: IsVolumeNotFound,
+		},
+		{
+			description:  "command not allowed in recycle state is rejected",
+			request:      Request{Command: recycle.Skip, Token: "admin-token", Volume: "cleaning"},
+			errorMatcher: recycle.IsCommandNotAllowed,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			managed := map[string]string{"persistentvolume.giantswarm.io/cleanup-on-release": "true"}
+
+			k8sClient := fake.NewSimpleClientset(
+				&apiv1.PersistentVolume{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "failed",
+						Labels:      managed,
+						Annotations: map[string]string{recycle.StateAnnotation: string(recycle.Failed)},
+					},
+					Status: apiv1.PersistentVolumeStatus{Phase: apiv1.VolumeReleased},
+				},
+				&apiv1.PersistentVolume{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "cleaning",
+						Labels:      managed,
+						Annotations: map[string]string{recycle.StateAnnotation: string(recycle.Cleaning)},
+					},
+					Status: apiv1.PersistentVolumeStatus{Phase: apiv1.VolumeBound},
+				},
+				&apiv1.PersistentVolume{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "unmanaged",
+						Annotations: map[string]string{recycle.StateAnnotation: string(recycle.Failed)},
+					},
+					Status: apiv1.PersistentVolumeStatus{Phase: apiv1.VolumeReleased},
+				},
+			)
+			k8sClient.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+				switch review.Spec.Token {
+				case "admin-token":
+					review.Status.Authenticated = true
+					review.Status.User.Username = "admin@example.com"
+				case "viewer-token":
+					review.Status.Authenticated = true
+					review.Status.User.Username = "viewer@example.com"
+				default:
+					review.Status.Error = "token expired"
+				}
+				return true, review, nil
+			})
+			k8sClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				review.Status.Allowed = review.Spec.User == "admin@example.com" && review.Spec.ResourceAttributes.Verb == "update"
+				return true, review, nil
+			})
+
+			var err error
+			var newService *Service
+			{
+				c := Config{
+					K8sClient: k8sClient,
+					Logger:    microloggertest.New(),
+
+					Handles: func(pv *apiv1.PersistentVolume) bool {
+						return labels.SelectorFromSet(managed).Matches(labels.Set(pv.Labels))
+					},
+				}
+				newService, err = New(c)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			response, err := newService.Apply(context.TODO(), tc.request)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("case %d unexpected error: %#v", i+1, err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("case %d expected error got nil", i+1)
+			case !tc.errorMatcher(err):
+				t.Fatalf("case %d unexpected error: %#v", i+1, err)
+			}
+
+			if tc.errorMatcher != nil {
+				return
+			}
+
+			if response.RequestedBy != tc.expectedRequestedBy {
+				t.Fatalf("case %d expected requested by %#q got %#q", i+1, tc.expectedRequestedBy, response.RequestedBy)
+			}
+
+			pv, err := k8sClient.CoreV1().PersistentVolumes().Get(tc.request.Volume, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+			if pv.Annotations[recycle.CommandAnnotation] != string(tc.request.Command) {
+				t.Fatalf("case %d expected command %#q got %#q", i+1, tc.request.Command, pv.Annotations[recycle.CommandAnnotation])
+			}
+			if pv.Annotations[recycle.CommandRequestedByAnnotation] != tc.expectedRequestedBy {
+				t.Fatalf("case %d expected requested by annotation %#q got %#q", i+1, tc.expectedRequestedBy, pv.Annotations[recycle.CommandRequestedByAnnotation])
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package cleanuppolicy
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var cacheNotSyncedError = &microerror.Error{
+	Kind: "cacheNotSyncedError",
+}
+
+// IsCacheNotSynced asserts cacheNotSyncedError.
+func IsCacheNotSynced(err error) bool {
+	return microerror.Cause(err) == cacheNotSyncedError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidPolicyError = &microerror.Error{
+	Kind: "invalidPolicyError",
+}
+
+// IsInvalidPolicy asserts invalidPolicyError.
+func IsInvalidPolicy(err error) bool {
+	return microerror.Cause(err) == invalidPolicyError
+}
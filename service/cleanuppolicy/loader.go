@@ -0,0 +1,209 @@
+// Package cleanuppolicy loads the cleanup policy custom resources into the
+// cleanup policy of the operator, so that they select the persistent volumes
+// which are cleaned once they are released.
+package cleanuppolicy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+)
+
+type Config struct {
+	// Cache is the informer cache the cleanup policy custom resources are
+	// watched and listed with. It is started by the loader.
+	Cache  cache.Cache
+	Logger micrologger.Logger
+	// Policy is the cleanup policy the rules of the cleanup policy custom
+	// resources are set on.
+	Policy *policy.Policy
+
+	// Strategies are the names of the scrub strategies cleanup policies may
+	// select.
+	Strategies []string
+}
+
+type Loader struct {
+	cache  cache.Cache
+	logger micrologger.Logger
+	policy *policy.Policy
+
+	strategies map[string]bool
+}
+
+func New(config Config) (*Loader, error) {
+	if config.Cache == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Cache must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+	if config.Policy == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Policy must not be empty")
+	}
+
+	if len(config.Strategies) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "config.Strategies must not be empty")
+	}
+
+	strategies := map[string]bool{}
+	for _, s := range config.Strategies {
+		strategies[s] = true
+	}
+
+	l := &Loader{
+		cache:  config.Cache,
+		logger: config.Logger,
+		policy: config.Policy,
+
+		strategies: strategies,
+	}
+
+	return l, nil
+}
+
+// Boot watches the cleanup policies until the given context is done and
+// loads them whenever one changes. Boot blocks until the cleanup policies
+// are listed and loaded for the first time, so that volumes are not
+// reconciled without their cleanup policies, which would release them. It
+// fails if they can not be loaded.
+func (l *Loader) Boot(ctx context.Context) error {
+	informer, err := l.cache.GetInformer(&v1alpha1.CleanupPolicy{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			l.handle(ctx, informer)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			l.handle(ctx, informer)
+		},
+		DeleteFunc: func(obj interface{}) {
+			l.handle(ctx, informer)
+		},
+	})
+
+	go func() {
+		err := l.cache.Start(ctx.Done())
+		if err != nil {
+			l.logger.LogCtx(ctx, "level", "error", "message", "failed watching cleanup policies", "stack", microerror.JSON(err))
+		}
+	}()
+
+	if !l.cache.WaitForCacheSync(ctx.Done()) {
+		return microerror.Maskf(cacheNotSyncedError, "cleanup policies")
+	}
+
+	err = l.Load(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// handle loads the cleanup policies after one of them changed. Changes seen
+// while the cache is filled for the first time are left to Boot.
+func (l *Loader) handle(ctx context.Context, informer cache.Informer) {
+	if !informer.HasSynced() {
+		return
+	}
+
+	l.load(ctx)
+}
+
+func (l *Loader) load(ctx context.Context) {
+	err := l.Load(ctx)
+	if err != nil {
+		l.logger.LogCtx(ctx, "level", "error", "message", "failed loading cleanup policies", "stack", microerror.JSON(err))
+	}
+}
+
+// Load lists the cleanup policy custom resources from the cache and sets
+// them as rules of the cleanup policy. Invalid cleanup policies are logged
+// and skipped.
+func (l *Loader) Load(ctx context.Context) error {
+	var list v1alpha1.CleanupPolicyList
+	err := l.cache.List(ctx, &list)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var rules []policy.Rule
+	for _, cp := range list.Items {
+		rule, err := l.toRule(cp)
+		if IsInvalidPolicy(err) {
+			l.logger.LogCtx(ctx, "level", "warning", "cleanuppolicy", cp.Name, "message", fmt.Sprintf("skipped invalid cleanup policy: %s", err))
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	l.policy.SetRules(rules)
+
+	return nil
+}
+
+// toRule converts the given cleanup policy custom resource into a rule.
+func (l *Loader) toRule(cp v1alpha1.CleanupPolicy) (policy.Rule, error) {
+	for _, s := range []string{cp.Spec.Strategy, cp.Spec.BlockStrategy} {
+		if s != "" && !l.strategies[s] {
+			return policy.Rule{}, microerror.Maskf(invalidPolicyError, "unknown strategy %#q", s)
+		}
+	}
+
+	selector := policy.Selector{
+		ClaimNamespaces: cp.Spec.Selector.ClaimNamespaces,
+		StorageClasses:  cp.Spec.Selector.StorageClasses,
+		VolumeSources:   cp.Spec.Selector.VolumeSources,
+	}
+	if cp.Spec.Selector.VolumeLabels != nil {
+		s, err := metav1.LabelSelectorAsSelector(cp.Spec.Selector.VolumeLabels)
+		if err != nil {
+			return policy.Rule{}, microerror.Maskf(invalidPolicyError, "invalid volume labels: %s", err)
+		}
+		selector.VolumeLabels = s
+	}
+
+	job := cp.Spec.Job
+	rule := policy.Rule{
+		Class: policy.Class{
+			BlockStrategy: cp.Spec.BlockStrategy,
+			GracePeriod:   cp.Spec.GracePeriod,
+			Image:         job.Image,
+			Parameters:    cp.Spec.Parameters,
+			Pod: cleaner.PodConfig{
+				Affinity:          job.Affinity,
+				FSGroup:           job.FSGroup,
+				NodeSelector:      job.NodeSelector,
+				PriorityClassName: job.PriorityClassName,
+				RunAsUser:         job.RunAsUser,
+				SeccompProfile:    job.SeccompProfile,
+				Tolerations:       job.Tolerations,
+			},
+			Resources: job.Resources,
+			Retries:   cp.Spec.Retries,
+			Strategy:  cp.Spec.Strategy,
+			Timeout:   job.Timeout,
+		},
+		Name:     cp.Name,
+		Priority: cp.Spec.Priority,
+		Selector: selector,
+	}
+
+	return rule, nil
+}
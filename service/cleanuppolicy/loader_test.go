@@ -0,0 +1,296 @@
+package cleanuppolicy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+)
+
+func Test_Loader_Load(t *testing.T) {
+	retries := 5
+	objects := []runtime.Object{
+		&v1alpha1.CleanupPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "local",
+			},
+			Spec: v1alpha1.CleanupPolicySpec{
+				GracePeriod: &metav1.Duration{Duration: 30 * time.Minute},
+				Job: v1alpha1.CleanupPolicySpecJob{
+					Image:   "busybox",
+					Timeout: metav1.Duration{Duration: time.Hour},
+				},
+				Priority: 10,
+				Retries:  &retries,
+				Selector: v1alpha1.CleanupPolicySpecSelector{
+					VolumeSources: []string{"local"},
+				},
+				Strategy: cleaner.ZeroFill,
+			},
+		},
+		&v1alpha1.CleanupPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "tenant",
+			},
+			Spec: v1alpha1.CleanupPolicySpec{
+				Priority: 100,
+				Selector: v1alpha1.CleanupPolicySpecSelector{
+					VolumeLabels: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"tenant": "a"},
+					},
+				},
+			},
+		},
+		&v1alpha1.CleanupPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "unknown-strategy",
+			},
+			Spec: v1alpha1.CleanupPolicySpec{
+				Priority: 1000,
+				Strategy: "shred",
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := v1alpha1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &policy.Policy{
+		Default: policy.Class{
+			Strategy: cleaner.DeleteFiles,
+		},
+	}
+
+	l := newLoader(t, newTestCache(scheme, objects...), p)
+
+	err = l.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		description    string
+		pv             *apiv1.PersistentVolume
+		expectedRule   string
+		expectedPolicy policy.Class
+	}{
+		{
+			description: "local volume of tenant matches policy with higher priority",
+			pv: &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"tenant": "a"},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					PersistentVolumeSource: apiv1.PersistentVolumeSource{
+						Local: &apiv1.LocalVolumeSource{Path: "/mnt/disks/ssd1"},
+					},
+				},
+			},
+			expectedRule: "tenant",
+			expectedPolicy: policy.Class{
+				Strategy: cleaner.DeleteFiles,
+			},
+		},
+		{
+			description: "local volume matches policy selecting volume source",
+			pv: &apiv1.PersistentVolume{
+				Spec: apiv1.PersistentVolumeSpec{
+					PersistentVolumeSource: apiv1.PersistentVolumeSource{
+						Local: &apiv1.LocalVolumeSource{Path: "/mnt/disks/ssd1"},
+					},
+				},
+			},
+			expectedRule: "local",
+			expectedPolicy: policy.Class{
+				GracePeriod: &metav1.Duration{Duration: 30 * time.Minute},
+				Image:       "busybox",
+				Retries:     &retries,
+				Strategy:    cleaner.ZeroFill,
+				Timeout:     metav1.Duration{Duration: time.Hour},
+			},
+		},
+		{
+			description: "host path volume matches no policy",
+			pv: &apiv1.PersistentVolume{
+				Spec: apiv1.PersistentVolumeSpec{
+					PersistentVolumeSource: apiv1.PersistentVolumeSource{
+						HostPath: &apiv1.HostPathVolumeSource{Path: "/data"},
+					},
+				},
+			},
+			expectedRule: "",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rule, ok := p.Match(tc.pv)
+			if tc.expectedRule == "" {
+				if ok {
+					t.Fatalf("case %d expected no rule got %#q", i+1, rule.Name)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("case %d expected rule %#q got none", i+1, tc.expectedRule)
+			}
+			if rule.Name != tc.expectedRule {
+				t.Fatalf("case %d expected rule %#q got %#q", i+1, tc.expectedRule, rule.Name)
+			}
+			if rule.Class.Strategy != tc.expectedPolicy.Strategy {
+				t.Fatalf("case %d expected strategy %#q got %#q", i+1, tc.expectedPolicy.Strategy, rule.Class.Strategy)
+			}
+			if !reflect.DeepEqual(rule.Class.GracePeriod, tc.expectedPolicy.GracePeriod) {
+				t.Fatalf("case %d expected grace period %v got %v", i+1, tc.expectedPolicy.GracePeriod, rule.Class.GracePeriod)
+			}
+			if rule.Class.Image != tc.expectedPolicy.Image {
+				t.Fatalf("case %d expected image %#q got %#q", i+1, tc.expectedPolicy.Image, rule.Class.Image)
+			}
+			if rule.Class.Timeout != tc.expectedPolicy.Timeout {
+				t.Fatalf("case %d expected timeout %s got %s", i+1, tc.expectedPolicy.Timeout.Duration, rule.Class.Timeout.Duration)
+			}
+			if !reflect.DeepEqual(rule.Class.Retries, tc.expectedPolicy.Retries) {
+				t.Fatalf("case %d expected retries %v got %v", i+1, tc.expectedPolicy.Retries, rule.Class.Retries)
+			}
+		})
+	}
+}
+
+func Test_Loader_Boot(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := v1alpha1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestCache(scheme, newNamespacePolicy("tenant-a"))
+	p := &policy.Policy{}
+	l := newLoader(t, c, p)
+
+	err = l.Boot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule, ok := p.Match(newClaimVolume("tenant-a"))
+	if !ok || rule.Name != "tenant-a" {
+		t.Fatalf("expected rule %#q after boot got %#q", "tenant-a", rule.Name)
+	}
+
+	informer, err := c.FakeInformerFor(&v1alpha1.CleanupPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	informer.Synced = true
+
+	cp := newNamespacePolicy("tenant-b")
+	err = c.ctrlClient.Create(context.Background(), cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	informer.Add(cp)
+
+	rule, ok = p.Match(newClaimVolume("tenant-b"))
+	if !ok || rule.Name != "tenant-b" {
+		t.Fatalf("expected rule %#q after change got %#q", "tenant-b", rule.Name)
+	}
+}
+
+func Test_Loader_Boot_NotSynced(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := v1alpha1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	synced := false
+	c := newTestCache(scheme)
+	c.Synced = &synced
+	l := newLoader(t, c, &policy.Policy{})
+
+	err = l.Boot(context.Background())
+	if !IsCacheNotSynced(err) {
+		t.Fatalf("expected cache not synced error got %#v", err)
+	}
+}
+
+// testCache serves the objects of a fake client through fake informers.
+type testCache struct {
+	*informertest.FakeInformers
+	ctrlClient client.Client
+}
+
+func newTestCache(scheme *runtime.Scheme, objects ...runtime.Object) *testCache {
+	return &testCache{
+		FakeInformers: &informertest.FakeInformers{Scheme: scheme},
+		ctrlClient:    ctrlfake.NewFakeClientWithScheme(scheme, objects...),
+	}
+}
+
+func (c *testCache) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	return c.ctrlClient.Get(ctx, key, obj)
+}
+
+func (c *testCache) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	return c.ctrlClient.List(ctx, list, opts...)
+}
+
+func newLoader(t *testing.T, c *testCache, p *policy.Policy) *Loader {
+	var strategies []string
+	for _, c := range cleaner.Builtin() {
+		strategies = append(strategies, c.Name())
+	}
+
+	config := Config{
+		Cache:  c,
+		Logger: microloggertest.New(),
+		Policy: p,
+
+		Strategies: strategies,
+	}
+	l, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return l
+}
+
+func newNamespacePolicy(namespace string) *v1alpha1.CleanupPolicy {
+	return &v1alpha1.CleanupPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: namespace,
+		},
+		Spec: v1alpha1.CleanupPolicySpec{
+			Selector: v1alpha1.CleanupPolicySpecSelector{
+				ClaimNamespaces: []string{namespace},
+			},
+		},
+	}
+}
+
+func newClaimVolume(namespace string) *apiv1.PersistentVolume {
+	return &apiv1.PersistentVolume{
+		Spec: apiv1.PersistentVolumeSpec{
+			ClaimRef: &apiv1.ObjectReference{
+				Name:      "data",
+				Namespace: namespace,
+			},
+		},
+	}
+}
@@ -0,0 +1,100 @@
+// Package collector provides Prometheus collectors exposing the state of the
+// persistent volumes managed by the operator.
+package collector
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+var volumesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName("pv_cleaner_operator", "recycle", "volumes"),
+	"Number of persistent volumes per phase and recycle state.",
+	[]string{"phase", "recycle_state"},
+	nil,
+)
+
+type VolumeConfig struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// Handles returns true for the persistent volumes managed by the
+	// operator.
+	Handles func(pv *apiv1.PersistentVolume) bool
+}
+
+// Volume counts the persistent volumes managed by the operator per phase and
+// recycle state whenever it is collected.
+type Volume struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+
+	handles func(pv *apiv1.PersistentVolume) bool
+}
+
+func NewVolume(config VolumeConfig) (*Volume, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+
+	if config.Handles == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Handles must not be empty")
+	}
+
+	v := &Volume{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		handles: config.Handles,
+	}
+
+	return v, nil
+}
+
+func (v *Volume) Describe(ch chan<- *prometheus.Desc) {
+	ch <- volumesDesc
+}
+
+func (v *Volume) Collect(ch chan<- prometheus.Metric) {
+	volumes, err := v.k8sClient.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+	if err != nil {
+		v.logger.LogCtx(context.Background(), "level", "error", "message", "failed collecting persistent volumes", "stack", microerror.JSON(microerror.Mask(err)))
+		return
+	}
+
+	type key struct {
+		phase string
+		state string
+	}
+
+	counts := map[key]int{}
+	for _, pv := range volumes.Items {
+		if !v.handles(&pv) {
+			continue
+		}
+
+		// Volumes which were never recycled by the operator are considered
+		// recycled, just like the controller does.
+		state := pv.Annotations[recycle.StateAnnotation]
+		if state == "" {
+			state = string(recycle.Recycled)
+		}
+
+		counts[key{phase: string(pv.Status.Phase), state: state}]++
+	}
+
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(volumesDesc, prometheus.GaugeValue, float64(n), k.phase, k.state)
+	}
+}
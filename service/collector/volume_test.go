@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Collector_Volume(t *testing.T) {
+	newVolume := func(name string, phase apiv1.PersistentVolumePhase, state recycle.State, managed bool) *apiv1.PersistentVolume {
+		pv := &apiv1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Status: apiv1.PersistentVolumeStatus{
+				Phase: phase,
+			},
+		}
+		if state != "" {
+			pv.Annotations = map[string]string{
+				recycle.StateAnnotation: string(state),
+			}
+		}
+		if managed {
+			pv.Labels = map[string]string{
+				"persistentvolume.giantswarm.io/cleanup-on-release": "true",
+			}
+		}
+		return pv
+	}
+
+	k8sClient := fake.NewSimpleClientset(
+		newVolume("bound-1", apiv1.VolumeBound, "", true),
+		newVolume("bound-2", apiv1.VolumeBound, recycle.Recycled, true),
+		newVolume("cleaning", apiv1.VolumeBound, recycle.Cleaning, true),
+		newVolume("queued", apiv1.VolumeReleased, recycle.Queued, true),
+		newVolume("unmanaged", apiv1.VolumeReleased, "", false),
+	)
+
+	c := VolumeConfig{
+		K8sClient: k8sClient,
+		Logger:    microloggertest.New(),
+
+		Handles: func(pv *apiv1.PersistentVolume) bool {
+			return pv.Labels["persistentvolume.giantswarm.io/cleanup-on-release"] == "true"
+		},
+	}
+	volumeCollector, err := NewVolume(c)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	expected := `
+# HELP pv_cleaner_operator_recycle_volumes Number of persistent volumes per phase and recycle state.
+# TYPE pv_cleaner_operator_recycle_volumes gauge
+pv_cleaner_operator_recycle_volumes{phase="Bound",recycle_state="Cleaning"} 1
+pv_cleaner_operator_recycle_volumes{phase="Bound",recycle_state="Recycled"} 2
+pv_cleaner_operator_recycle_volumes{phase="Released",recycle_state="Queued"} 1
+`
+
+	err = testutil.CollectAndCompare(volumeCollector, strings.NewReader(expected))
+	if err != nil {
+		t.Fatalf("unexpected metrics: %s", err)
+	}
+}
@@ -8,18 +8,27 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
 	v1 "github.com/giantswarm/pv-cleaner-operator/service/controller/v1"
 )
 
 const (
-	cleanupLabel = "persistentvolume.giantswarm.io/cleanup-on-release"
+	// CleanupLabel marks persistent volumes which are cleaned by the operator
+	// once they are released.
+	CleanupLabel = policy.VolumeLabel
 )
 
 type PersistentVolumeConfig struct {
-	K8sClient k8sclient.Interface
-	Logger    micrologger.Logger
+	Container     cleaner.ContainerConfig
+	EventRecorder record.EventRecorder
+	K8sClient     k8sclient.Interface
+	Logger        micrologger.Logger
+	Policy        *policy.Policy
 
+	Namespace   string
 	ProjectName string
 }
 
@@ -30,13 +39,22 @@ type PersistentVolume struct {
 func NewPersistentVolume(config PersistentVolumeConfig) (*PersistentVolume, error) {
 	var err error
 
+	if config.EventRecorder == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.EventRecorder must not be empty")
+	}
 	if config.K8sClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
 	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
 	}
+	if config.Policy == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Policy must not be empty")
+	}
 
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.Namespace must not be empty")
+	}
 	if config.ProjectName == "" {
 		return nil, microerror.Maskf(invalidConfigError, "config.ProjectName must not be empty")
 	}
@@ -44,9 +62,13 @@ func NewPersistentVolume(config PersistentVolumeConfig) (*PersistentVolume, erro
 	var v1ResourceSet *controller.ResourceSet
 	{
 		c := v1.ResourceSetConfig{
-			K8sClient: config.K8sClient,
-			Logger:    config.Logger,
+			Container:     config.Container,
+			EventRecorder: config.EventRecorder,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+			Policy:        config.Policy,
 
+			Namespace:   config.Namespace,
 			ProjectName: config.ProjectName,
 		}
 
@@ -67,9 +89,10 @@ func NewPersistentVolume(config PersistentVolumeConfig) (*PersistentVolume, erro
 			ResourceSets: []*controller.ResourceSet{
 				v1ResourceSet,
 			},
-			Selector: labels.SelectorFromSet(map[string]string{
-				cleanupLabel: "true",
-			}),
+			// Every volume is watched, since cleanup policies select volumes
+			// without cleanup label. The resource set only handles the volumes
+			// which are managed by the operator.
+			Selector: labels.Everything(),
 
 			Name: config.ProjectName,
 		}
@@ -0,0 +1,544 @@
+package persistentvolume
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+const (
+	pvcTerminatingPhase = "Terminating"
+	jobLabel            = "job-name"
+
+	// volumeFailedReason is the failure reason of volumes Kubernetes failed
+	// to reclaim.
+	volumeFailedReason = "VolumeFailed"
+)
+
+// startCleaning queues the released volume for cleaning, or recreates it
+// without its claim reference when it lost its cleanup claim, so that it can
+// be bound by a new cleanup claim. Volumes which were recycled before are kept
+// for their grace period first, and as long as they are held. Meanwhile they
+// are restored to a recreated claim which opts in to it.
+func (r *Resource) startCleaning(ctx context.Context, pv *apiv1.PersistentVolume, transition recycle.Transition) error {
+	if transition.State == recycle.Recycled {
+		restored, err := r.restoreClaim(ctx, pv)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if restored {
+			return nil
+		}
+
+		waiting, err := r.waitGracePeriod(ctx, pv)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if waiting {
+			return nil
+		}
+
+		// The recycle duration is measured from the release of the volume,
+		// including the grace period.
+		recycleStartedAt := getVolumeAnnotation(pv, releasedAtAnnotation)
+		if recycleStartedAt == "" {
+			recycleStartedAt = time.Now().UTC().Format(time.RFC3339)
+		}
+		resetRecycleAnnotations(pv)
+		pv.Annotations[recycleStartedAtAnnotation] = recycleStartedAt
+		// The claim reference still points to the claim of the workload,
+		// remember it so that it stays known once the volume got scrubbed.
+		if ref := pv.Spec.ClaimRef; ref != nil {
+			pv.Annotations[previousClaimAnnotation] = fmt.Sprintf("%s/%s", ref.Namespace, ref.Name)
+		}
+	}
+
+	err := r.updateRecycleState(ctx, pv, transition.Next)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// createClaim creates the cleanup claim for the available volume.
+func (r *Resource) createClaim(ctx context.Context, pv *apiv1.PersistentVolume) error {
+	pvcdef := newPvc(pv, r.namespace)
+	_, err := r.k8sClient.CoreV1().PersistentVolumeClaims(r.namespace).Create(pvcdef)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.eventRecorder.Eventf(pv, apiv1.EventTypeNormal, claimCreatedReason, "created cleanup claim %#q", pvcdef.Name)
+
+	return nil
+}
+
+// runJob creates the cleanup job for the volume bound to its cleanup claim
+// and waits for the job to succeed. Afterwards the job, its pods and the
+// cleanup claim are removed.
+func (r *Resource) runJob(ctx context.Context, pv *apiv1.PersistentVolume, transition recycle.Transition) error {
+	pvcName := claimName(pv)
+	pvc, err := r.k8sClient.CoreV1().PersistentVolumeClaims(r.namespace).Get(pvcName, metav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if pvc.ObjectMeta.DeletionTimestamp != nil {
+		r.logger.LogCtx(ctx, "pvc", pvcName, "waiting for pvc to release a pv", pv.Name)
+		return nil
+	}
+
+	c, err := r.volumeCleaner(pv)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	parameters, err := r.volumeParameters(pv)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	pod, resources, err := r.volumePod(pv)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	// Node-local volumes can only be cleaned on their node.
+	pod.Affinity = pinToNode(pod.Affinity, volumeNodeSelector(pv))
+
+	class := r.volumePolicy(pv)
+	container := r.container
+	if class.Image != "" {
+		container.Image = class.Image
+	}
+	jobConfig := cleaner.JobConfig{
+		Claim:      pvc,
+		Container:  container,
+		Parameters: parameters,
+		Pod:        pod,
+		Resources:  resources,
+		Timeout:    class.Timeout.Duration,
+	}
+
+	cleanupJobDef, err := c.NewJob(jobConfig)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cleanupJob, err := r.k8sClient.BatchV1().Jobs(r.namespace).Create(cleanupJobDef)
+	if errors.IsAlreadyExists(err) {
+		cleanupJob, err = r.k8sClient.BatchV1().Jobs(r.namespace).Get(cleanupJobDef.Name, metav1.GetOptions{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	} else if err != nil {
+		return microerror.Mask(err)
+	} else {
+		r.eventRecorder.Eventf(pv, apiv1.EventTypeNormal, jobStartedReason, "started cleanup job %#q using method %#q", cleanupJob.Name, cleanupJob.Annotations[cleaner.MethodAnnotation])
+		cleanupStartedCounter.WithLabelValues(policy.StorageClass(pv)).Inc()
+	}
+
+	// A job which is being deleted was handled already, e.g. it failed and
+	// the retry was recorded. It is recreated once it is gone.
+	if cleanupJob.DeletionTimestamp != nil {
+		r.logger.LogCtx(ctx, "job", cleanupJob.Name, "waiting for job to be removed before cleaning pv", pv.Name)
+		return nil
+	}
+
+	err = r.ensureScrub(ctx, pv, c.Name(), cleanupJob)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cleanupJob, err = r.recordImageDigest(ctx, cleanupJob)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	switch c.Result(cleanupJob) {
+	case cleaner.ResultRunning:
+		r.logger.LogCtx(ctx, "job", cleanupJob.Name, "waiting for job to complete cleanup of pv", pv.Name)
+		return nil
+	case cleaner.ResultFailed:
+		err = r.handleFailedJob(ctx, pv, cleanupJob)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		return nil
+	}
+
+	r.eventRecorder.Eventf(pv, apiv1.EventTypeNormal, jobSucceededReason, "cleanup job %#q succeeded after %s", cleanupJob.Name, jobDuration(cleanupJob))
+	cleanupSucceededCounter.WithLabelValues(policy.StorageClass(pv)).Inc()
+
+	terminationMessage, err := r.terminationMessage(ctx, cleanupJob)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	status := v1alpha1.VolumeScrubStatus{
+		Phase: v1alpha1.VolumeScrubPhaseSucceeded,
+	}
+	if bytes, ok := cleaner.ReclaimedBytes(terminationMessage); ok {
+		reclaimedBytesCounter.WithLabelValues(policy.StorageClass(pv)).Add(float64(bytes))
+		status.BytesRemoved = &bytes
+	}
+
+	termination, err := r.lastTermination(ctx, cleanupJob)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if termination != nil {
+		status.ExitCode = &termination.ExitCode
+	}
+
+	err = r.finishScrub(ctx, pv, cleanupJob, status)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.deleteJob(ctx, cleanupJob.Name, &metav1.DeleteOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if err := r.k8sClient.CoreV1().PersistentVolumeClaims(r.namespace).Delete(pvcName, &metav1.DeleteOptions{}); err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Remember the scrub method on the volume, so that it stays visible once
+	// the volume is recycled and the job is gone.
+	if method, ok := cleanupJob.Annotations[cleaner.MethodAnnotation]; ok {
+		pv.Annotations[methodAnnotation] = method
+	}
+	if digest, ok := cleanupJob.Annotations[cleaner.ImageDigestAnnotation]; ok {
+		pv.Annotations[imageDigestAnnotation] = digest
+	}
+
+	err = r.updateRecycleState(ctx, pv, transition.Next)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// handleFailedJob removes the failed cleanup job of the volume. As long as
+// the volume has retries left, the retry counter of the volume is increased,
+// so that the job gets recreated during the next reconciliation. Once all
+// retries are used up, the cleanup claim is removed as well and the volume
+// is marked as failed.
+func (r *Resource) handleFailedJob(ctx context.Context, pv *apiv1.PersistentVolume, job *batchv1.Job) error {
+	reason, message := jobFailure(job)
+
+	terminationMessage, err := r.terminationMessage(ctx, job)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if terminationMessage != "" {
+		message = terminationMessage
+	}
+
+	r.logger.LogCtx(ctx, "level", "warning", "job", job.Name, "message", fmt.Sprintf("job failed to clean up pv %#q: %s: %s", pv.Name, reason, message))
+	cleanupFailedCounter.WithLabelValues(policy.StorageClass(pv)).Inc()
+	r.eventRecorder.Eventf(pv, apiv1.EventTypeWarning, jobFailedReason, "cleanup job %#q failed after %s: %s: %s", job.Name, jobDuration(job), reason, message)
+
+	{
+		status := v1alpha1.VolumeScrubStatus{
+			Phase:   v1alpha1.VolumeScrubPhaseFailed,
+			Reason:  reason,
+			Message: message,
+		}
+
+		termination, err := r.lastTermination(ctx, job)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if termination != nil {
+			status.ExitCode = &termination.ExitCode
+		}
+
+		err = r.finishScrub(ctx, pv, job, status)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = r.deleteJob(ctx, job.Name, &metav1.DeleteOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	retried, err := r.retry(ctx, pv)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if retried {
+		return nil
+	}
+
+	err = r.fail(ctx, pv, reason, message)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// retry increases the retry counter of the volume and records the time of
+// the retry, as long as the volume has retries left. It returns false if all
+// retries are used up.
+func (r *Resource) retry(ctx context.Context, pv *apiv1.PersistentVolume) (bool, error) {
+	retries := volumeRetries(pv)
+	if retries >= r.maxRetries(pv) {
+		return false, nil
+	}
+
+	updatedpv := pv.DeepCopy()
+	if updatedpv.Annotations == nil {
+		updatedpv.Annotations = map[string]string{}
+	}
+	updatedpv.Annotations[retriesAnnotation] = strconv.Itoa(retries + 1)
+	updatedpv.Annotations[retriedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+
+	_, err := r.k8sClient.CoreV1().PersistentVolumes().Update(updatedpv)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "retrying cleanup", retries+1)
+	r.eventRecorder.Eventf(pv, apiv1.EventTypeNormal, jobRetriedReason, "retrying cleanup, retry %d of %d", retries+1, r.maxRetries(pv))
+
+	return true, nil
+}
+
+// fail removes the cleanup job, its pods and the cleanup claim of the volume
+// and marks the volume as failed.
+func (r *Resource) fail(ctx context.Context, pv *apiv1.PersistentVolume, reason, message string) error {
+	err := r.abortScrub(ctx, pv, reason, message)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.deleteJob(ctx, jobName(pv), &metav1.DeleteOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.k8sClient.CoreV1().PersistentVolumeClaims(r.namespace).Delete(claimName(pv), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+
+	err = r.markFailed(ctx, pv, reason, message)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// deleteJob deletes the cleanup job with the given name and its pods. The
+// given delete options are used for deleting the pods.
+func (r *Resource) deleteJob(ctx context.Context, name string, options *metav1.DeleteOptions) error {
+	err := r.k8sClient.BatchV1().Jobs(r.namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+
+	podSelector := labels.Set(map[string]string{jobLabel: name})
+	listOptions := metav1.ListOptions{LabelSelector: podSelector.AsSelector().String()}
+	err = r.k8sClient.CoreV1().Pods(r.namespace).DeleteCollection(options, listOptions)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// recordImageDigest annotates the cleanup job with the digest of the image its
+// pods run, once a pod reports it. Jobs already carrying the digest, e.g.
+// because their image is pinned to a digest, are returned unchanged.
+func (r *Resource) recordImageDigest(ctx context.Context, job *batchv1.Job) (*batchv1.Job, error) {
+	if _, ok := job.Annotations[cleaner.ImageDigestAnnotation]; ok {
+		return job, nil
+	}
+
+	podSelector := labels.Set(map[string]string{jobLabel: job.Name})
+	listOptions := metav1.ListOptions{LabelSelector: podSelector.AsSelector().String()}
+	pods, err := r.k8sClient.CoreV1().Pods(r.namespace).List(listOptions)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var digest string
+	for _, p := range pods.Items {
+		for _, s := range p.Status.ContainerStatuses {
+			if d := cleaner.ImageDigest(s.ImageID); d != "" {
+				digest = d
+			}
+		}
+	}
+
+	if digest == "" {
+		return job, nil
+	}
+
+	updatedJob := job.DeepCopy()
+	if updatedJob.Annotations == nil {
+		updatedJob.Annotations = map[string]string{}
+	}
+	updatedJob.Annotations[cleaner.ImageDigestAnnotation] = digest
+
+	updatedJob, err = r.k8sClient.BatchV1().Jobs(r.namespace).Update(updatedJob)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "job", job.Name, "recorded image digest", digest)
+
+	return updatedJob, nil
+}
+
+// terminationMessage returns the termination message of the most recently
+// terminated container of the pods of the given job.
+func (r *Resource) terminationMessage(ctx context.Context, job *batchv1.Job) (string, error) {
+	podSelector := labels.Set(map[string]string{jobLabel: job.Name})
+	listOptions := metav1.ListOptions{LabelSelector: podSelector.AsSelector().String()}
+	pods, err := r.k8sClient.CoreV1().Pods(r.namespace).List(listOptions)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	var message string
+	var finishedAt time.Time
+	for _, p := range pods.Items {
+		for _, s := range p.Status.ContainerStatuses {
+			t := s.State.Terminated
+			if t == nil || t.Message == "" {
+				continue
+			}
+			if t.FinishedAt.Time.Before(finishedAt) {
+				continue
+			}
+
+			message = strings.TrimSpace(t.Message)
+			finishedAt = t.FinishedAt.Time
+		}
+	}
+
+	return message, nil
+}
+
+// markFailed updates the volume with the Failed recycle state and the given
+// failure reason and message. The claim reference of the volume is kept, so
+// that the volume stays released and can not be bound by any other claim
+// before it got scrubbed.
+func (r *Resource) markFailed(ctx context.Context, pv *apiv1.PersistentVolume, reason, message string) error {
+	updatedpv := pv.DeepCopy()
+	if updatedpv.Annotations == nil {
+		updatedpv.Annotations = map[string]string{}
+	}
+	updatedpv.Annotations[recycleStateAnnotation] = string(recycle.Failed)
+	updatedpv.Annotations[stateChangedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	updatedpv.Annotations[failureReasonAnnotation] = reason
+	updatedpv.Annotations[failureMessageAnnotation] = message
+	setSlotLabel(updatedpv)
+
+	_, err := r.k8sClient.CoreV1().PersistentVolumes().Update(updatedpv)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	observeStateDuration(pv)
+
+	r.logger.LogCtx(ctx, "level", "warning", "persistentvolume", pv.Name, "message", fmt.Sprintf("marked volume as failed: %s", reason))
+	r.eventRecorder.Eventf(pv, apiv1.EventTypeWarning, recycleFailedReason, "marked volume as failed: %s: %s", reason, message)
+
+	return nil
+}
+
+// updateRecycleState updates the volume with the given recycle state and
+// records the time of the transition. Volumes entering Cleaning get the
+// cleanup finalizer, recycled volumes get it removed. Queued volumes keep
+// their claim reference.
+func (r *Resource) updateRecycleState(ctx context.Context, pv *apiv1.PersistentVolume, state recycle.State) error {
+	current := pv
+	claimRef := pv.Spec.ClaimRef
+
+	pv, err := r.newRecycleStateAnnotation(pv, string(state))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if state == recycle.Queued {
+		pv.Spec.ClaimRef = claimRef
+	}
+	pv.Annotations[stateChangedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	delete(pv.Annotations, retriedAtAnnotation)
+
+	switch state {
+	case recycle.Cleaning:
+		addCleanupFinalizer(pv)
+	case recycle.Recycled:
+		removeCleanupFinalizer(pv)
+		delete(pv.Annotations, recycleStartedAtAnnotation)
+	}
+
+	_, err = r.k8sClient.CoreV1().PersistentVolumes().Update(pv)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	observeStateDuration(current)
+	if state == recycle.Recycled {
+		observeRecycleDuration(current)
+	}
+
+	r.recordStateEvent(pv, state)
+
+	return nil
+}
+
+// resetRecycleAnnotations removes the annotations describing the previous
+// recycling of the volume, so that it can be recycled again.
+func resetRecycleAnnotations(pv *apiv1.PersistentVolume) {
+	if pv.Annotations == nil {
+		pv.Annotations = map[string]string{}
+	}
+
+	delete(pv.Annotations, releasedAtAnnotation)
+	delete(pv.Annotations, cleanupAfterAnnotation)
+	delete(pv.Annotations, methodAnnotation)
+	delete(pv.Annotations, previousClaimAnnotation)
+	delete(pv.Annotations, retriesAnnotation)
+	delete(pv.Annotations, failureReasonAnnotation)
+	delete(pv.Annotations, failureMessageAnnotation)
+}
+
+// jobFailure returns reason and message of the failed condition of the given
+// job.
+func jobFailure(job *batchv1.Job) (string, string) {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == apiv1.ConditionTrue {
+			return c.Reason, c.Message
+		}
+	}
+
+	return "JobFailed", fmt.Sprintf("job %#q failed", job.Name)
+}
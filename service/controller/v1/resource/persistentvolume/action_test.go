@@ -0,0 +1,321 @@
+package persistentvolume
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Resource_handleFailedJob(t *testing.T) {
+	testCases := []struct {
+		description             string
+		retries                 string
+		expectedRecycleState    string
+		expectedRetries         string
+		expectedFailureReason   string
+		expectedFailureMessage  string
+		expectedClaimRemoved    bool
+		expectedClaimRefPresent bool
+		expectedEvents          []string
+	}{
+		{
+			description:             "volume with retries left is retried",
+			retries:                 "1",
+			expectedRecycleState:    cleaning,
+			expectedRetries:         "2",
+			expectedClaimRemoved:    false,
+			expectedClaimRefPresent: true,
+			expectedEvents: []string{
+				"Warning CleanupJobFailed",
+				"Normal CleanupJobRetried",
+			},
+		},
+		{
+			description:             "volume without retries left is marked as failed",
+			retries:                 "3",
+			expectedRecycleState:    "Failed",
+			expectedRetries:         "3",
+			expectedFailureReason:   "BackoffLimitExceeded",
+			expectedFailureMessage:  "rm: can't remove '/scrub/data': Read-only file system",
+			expectedClaimRemoved:    true,
+			expectedClaimRefPresent: true,
+			expectedEvents: []string{
+				"Warning CleanupJobFailed",
+				"Warning RecycleFailed",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume",
+					Annotations: map[string]string{
+						recycleStateAnnotation: cleaning,
+						retriesAnnotation:      tc.retries,
+					},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					ClaimRef: &apiv1.ObjectReference{
+						Namespace: metav1.NamespaceSystem,
+						Name:      "pv-cleaner-claim-TestPersistentVolume",
+					},
+				},
+			}
+			pvc := &apiv1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pv-cleaner-claim-TestPersistentVolume",
+					Namespace: metav1.NamespaceSystem,
+				},
+			}
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume",
+					Namespace: metav1.NamespaceSystem,
+					UID:       types.UID("6d1fd26a-0f5b-4e8c-9a3e-1c2b3d4e5f60"),
+				},
+				Status: batchv1.JobStatus{
+					Failed: 1,
+					Conditions: []batchv1.JobCondition{
+						{
+							Type:    batchv1.JobFailed,
+							Status:  apiv1.ConditionTrue,
+							Reason:  "BackoffLimitExceeded",
+							Message: "Job has reached the specified backoff limit",
+						},
+					},
+				},
+			}
+			pod := &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume-x7k2p",
+					Namespace: metav1.NamespaceSystem,
+					Labels: map[string]string{
+						jobLabel: job.Name,
+					},
+				},
+				Status: apiv1.PodStatus{
+					ContainerStatuses: []apiv1.ContainerStatus{
+						{
+							State: apiv1.ContainerState{
+								Terminated: &apiv1.ContainerStateTerminated{
+									ExitCode: 1,
+									Message:  "rm: can't remove '/scrub/data': Read-only file system\n",
+								},
+							},
+						},
+					},
+				},
+			}
+
+			scrub := &v1alpha1.VolumeScrub{
+				TypeMeta: v1alpha1.NewVolumeScrubTypeMeta(),
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume-6d1fd26a",
+				},
+				Status: v1alpha1.VolumeScrubStatus{
+					Phase: v1alpha1.VolumeScrubPhaseRunning,
+				},
+			}
+
+			k8sClient := fake.NewSimpleClientset(pv, pvc, job, pod)
+			ctrlClient := newCtrlClient(scrub)
+			eventRecorder := record.NewFakeRecorder(len(tc.expectedEvents))
+
+			var err error
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					Cleaners:      cleaner.Builtin(),
+					CtrlClient:    ctrlClient,
+					EventRecorder: eventRecorder,
+					K8sClient:     k8sClient,
+					Logger:        microloggertest.New(),
+					Namespace:     metav1.NamespaceSystem,
+					Policy:        &policy.Policy{},
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			err = newResource.handleFailedJob(context.TODO(), pv, job)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned handling failed job: %s\n", i+1, err)
+			}
+
+			result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+
+			if result.Annotations[recycleStateAnnotation] != tc.expectedRecycleState {
+				t.Fatalf("case %d expected recycle state %#q got %#q", i+1, tc.expectedRecycleState, result.Annotations[recycleStateAnnotation])
+			}
+			if result.Annotations[retriesAnnotation] != tc.expectedRetries {
+				t.Fatalf("case %d expected retries %#q got %#q", i+1, tc.expectedRetries, result.Annotations[retriesAnnotation])
+			}
+			if result.Annotations[failureReasonAnnotation] != tc.expectedFailureReason {
+				t.Fatalf("case %d expected failure reason %#q got %#q", i+1, tc.expectedFailureReason, result.Annotations[failureReasonAnnotation])
+			}
+			if result.Annotations[failureMessageAnnotation] != tc.expectedFailureMessage {
+				t.Fatalf("case %d expected failure message %#q got %#q", i+1, tc.expectedFailureMessage, result.Annotations[failureMessageAnnotation])
+			}
+			if (result.Spec.ClaimRef != nil) != tc.expectedClaimRefPresent {
+				t.Fatalf("case %d expected claim reference present to be %t got %#v", i+1, tc.expectedClaimRefPresent, result.Spec.ClaimRef)
+			}
+
+			for _, expectedEvent := range tc.expectedEvents {
+				select {
+				case event := <-eventRecorder.Events:
+					if !strings.HasPrefix(event, expectedEvent+" ") {
+						t.Fatalf("case %d expected event %#q got %#q", i+1, expectedEvent, event)
+					}
+				default:
+					t.Fatalf("case %d expected event %#q got none", i+1, expectedEvent)
+				}
+			}
+
+			err = ctrlClient.Get(context.TODO(), types.NamespacedName{Name: scrub.Name}, scrub)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting scrub: %s\n", i+1, err)
+			}
+			if scrub.Status.Phase != v1alpha1.VolumeScrubPhaseFailed {
+				t.Fatalf("case %d expected scrub phase %#q got %#q", i+1, v1alpha1.VolumeScrubPhaseFailed, scrub.Status.Phase)
+			}
+			if scrub.Status.Reason != "BackoffLimitExceeded" {
+				t.Fatalf("case %d expected scrub reason %#q got %#q", i+1, "BackoffLimitExceeded", scrub.Status.Reason)
+			}
+			if scrub.Status.ExitCode == nil || *scrub.Status.ExitCode != 1 {
+				t.Fatalf("case %d expected scrub exit code %d got %v", i+1, 1, scrub.Status.ExitCode)
+			}
+
+			_, err = k8sClient.BatchV1().Jobs(metav1.NamespaceSystem).Get(job.Name, metav1.GetOptions{})
+			if !errors.IsNotFound(err) {
+				t.Fatalf("case %d expected job to be removed, got %#v", i+1, err)
+			}
+
+			_, err = k8sClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceSystem).Get(pvc.Name, metav1.GetOptions{})
+			if errors.IsNotFound(err) != tc.expectedClaimRemoved {
+				t.Fatalf("case %d expected claim removed to be %t, got %#v", i+1, tc.expectedClaimRemoved, err)
+			}
+		})
+	}
+}
+
+func Test_Resource_runJob_TerminatingJob(t *testing.T) {
+	pv := &apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "TestPersistentVolume",
+			Annotations: map[string]string{
+				recycleStateAnnotation: cleaning,
+				retriesAnnotation:      "1",
+			},
+		},
+		Spec: apiv1.PersistentVolumeSpec{
+			ClaimRef: &apiv1.ObjectReference{
+				Namespace: metav1.NamespaceSystem,
+				Name:      "pv-cleaner-claim-TestPersistentVolume",
+			},
+		},
+		Status: apiv1.PersistentVolumeStatus{
+			Phase: apiv1.VolumeBound,
+		},
+	}
+	pvc := &apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pv-cleaner-claim-TestPersistentVolume",
+			Namespace: metav1.NamespaceSystem,
+		},
+		Spec: apiv1.PersistentVolumeClaimSpec{
+			VolumeName: pv.Name,
+		},
+	}
+	deletedAt := metav1.Now()
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume",
+			Namespace:         metav1.NamespaceSystem,
+			DeletionTimestamp: &deletedAt,
+		},
+		Status: batchv1.JobStatus{
+			Failed: 1,
+			Conditions: []batchv1.JobCondition{
+				{
+					Type:   batchv1.JobFailed,
+					Status: apiv1.ConditionTrue,
+					Reason: "BackoffLimitExceeded",
+				},
+			},
+		},
+	}
+
+	k8sClient := fake.NewSimpleClientset(pv, pvc, job)
+	eventRecorder := record.NewFakeRecorder(10)
+
+	var err error
+	var newResource *Resource
+	{
+		resourceConfig := Config{
+			Cleaners:      cleaner.Builtin(),
+			CtrlClient:    newCtrlClient(),
+			EventRecorder: eventRecorder,
+			K8sClient:     k8sClient,
+			Logger:        microloggertest.New(),
+			Namespace:     metav1.NamespaceSystem,
+			Policy:        &policy.Policy{},
+		}
+		newResource, err = New(resourceConfig)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	transition, err := recycle.NextTransition(apiv1.VolumeBound, recycle.Cleaning)
+	if err != nil {
+		t.Fatalf("unexpected error returned getting transition: %s\n", err)
+	}
+
+	err = newResource.runJob(context.TODO(), pv, transition)
+	if err != nil {
+		t.Fatalf("unexpected error returned running job: %s\n", err)
+	}
+
+	result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error returned getting volume: %s\n", err)
+	}
+	if result.Annotations[retriesAnnotation] != "1" {
+		t.Fatalf("expected retries %#q got %#q", "1", result.Annotations[retriesAnnotation])
+	}
+	if result.Annotations[recycleStateAnnotation] != cleaning {
+		t.Fatalf("expected recycle state %#q got %#q", cleaning, result.Annotations[recycleStateAnnotation])
+	}
+
+	select {
+	case event := <-eventRecorder.Events:
+		t.Fatalf("expected no event got %#q", event)
+	default:
+	}
+
+	_, err = k8sClient.BatchV1().Jobs(metav1.NamespaceSystem).Get(job.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected terminating job to be left alone, got %#v", err)
+	}
+}
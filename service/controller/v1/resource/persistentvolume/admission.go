@@ -0,0 +1,189 @@
+package persistentvolume
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+const (
+	hostnameLabel = "kubernetes.io/hostname"
+	// slotLabel is set on volumes which are queued for or occupy a cleanup
+	// slot, so that admission only lists these volumes.
+	slotLabel      = "pv-cleaner-operator.giantswarm.io/cleanup-slot"
+	slotLabelValue = "true"
+)
+
+// slots counts the volumes being cleaned, in total, per policy scope and per
+// node.
+type slots struct {
+	total  int
+	scopes map[string]int
+	nodes  map[string]int
+}
+
+func newSlots() *slots {
+	return &slots{
+		scopes: map[string]int{},
+		nodes:  map[string]int{},
+	}
+}
+
+// take occupies a slot in the given policy scope on the given node.
+func (s *slots) take(scope, node string) {
+	s.total++
+	s.scopes[scope]++
+	if node != "" {
+		s.nodes[node]++
+	}
+}
+
+// setSlotLabel sets the slot label on the given volume if its recycle state
+// is one of the states admission counts, and removes it otherwise.
+func setSlotLabel(pv *apiv1.PersistentVolume) {
+	switch recycle.State(getVolumeAnnotation(pv, recycleStateAnnotation)) {
+	case recycle.Queued, recycle.Cleaning, recycle.Teardown:
+		if pv.Labels == nil {
+			pv.Labels = map[string]string{}
+		}
+		pv.Labels[slotLabel] = slotLabelValue
+	default:
+		delete(pv.Labels, slotLabel)
+	}
+}
+
+// admit checks whether cleaning the queued volume stays within the
+// concurrency limits of the policy. Queued volumes are admitted first-in,
+// first-out. Volumes which exceed a limit do not block volumes queued after
+// them which stay within the limits, e.g. volumes of another storage class.
+// Held volumes are not admitted and do not block any other volume.
+func (r *Resource) admit(ctx context.Context, pv *apiv1.PersistentVolume, transition recycle.Transition) error {
+	if isHeld(pv) {
+		r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "holding queued volume", holdAnnotation)
+		return nil
+	}
+
+	o := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", slotLabel, slotLabelValue),
+	}
+	volumes, err := r.k8sClient.CoreV1().PersistentVolumes().List(o)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	inUse := newSlots()
+	var queue []apiv1.PersistentVolume
+	for _, v := range volumes.Items {
+		switch recycle.State(getVolumeAnnotation(&v, recycleStateAnnotation)) {
+		case recycle.Cleaning, recycle.Teardown:
+			r.take(inUse, &v)
+		case recycle.Queued:
+			if !isHeld(&v) {
+				queue = append(queue, v)
+			}
+		}
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		a, b := queuedAt(&queue[i]), queuedAt(&queue[j])
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return queue[i].Name < queue[j].Name
+	})
+
+	for i, v := range queue {
+		if !r.fits(inUse, &v) {
+			if v.Name == pv.Name {
+				r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "waiting for free cleanup slot", i+1)
+				return nil
+			}
+			continue
+		}
+
+		if v.Name == pv.Name {
+			err = r.updateRecycleState(ctx, pv, transition.Next)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+
+		r.take(inUse, &v)
+	}
+
+	// The volume was not queued when the volumes were listed, e.g. because it
+	// lost its claim reference while queued.
+	if r.fits(inUse, pv) {
+		err = r.updateRecycleState(ctx, pv, transition.Next)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// fits checks whether one more volume like the given one can be cleaned
+// without exceeding the concurrency limits.
+func (r *Resource) fits(inUse *slots, pv *apiv1.PersistentVolume) bool {
+	concurrency := r.policy.Concurrency
+	if concurrency.Max > 0 && inUse.total >= concurrency.Max {
+		return false
+	}
+
+	scope, class := r.volumePolicyScope(pv)
+	if max := class.MaxConcurrent; max != nil && *max > 0 && inUse.scopes[scope] >= *max {
+		return false
+	}
+
+	node := volumeNode(pv)
+	if concurrency.MaxPerNode > 0 && node != "" && inUse.nodes[node] >= concurrency.MaxPerNode {
+		return false
+	}
+
+	return true
+}
+
+// take occupies a slot for the given volume in the policy scope its
+// maxConcurrent limit comes from.
+func (r *Resource) take(inUse *slots, pv *apiv1.PersistentVolume) {
+	scope, _ := r.volumePolicyScope(pv)
+	inUse.take(scope, volumeNode(pv))
+}
+
+// queuedAt returns the time the volume was queued. Volumes without recorded
+// transition time are considered queued first.
+func queuedAt(pv *apiv1.PersistentVolume) time.Time {
+	t, err := time.Parse(time.RFC3339, getVolumeAnnotation(pv, stateChangedAtAnnotation))
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// volumeNode returns the name of the node the given node-local volume is
+// pinned to, or an empty string if it is not pinned to a single node.
+func volumeNode(pv *apiv1.PersistentVolume) string {
+	nodeSelector := volumeNodeSelector(pv)
+	if nodeSelector == nil || len(nodeSelector.NodeSelectorTerms) != 1 {
+		return ""
+	}
+
+	for _, e := range nodeSelector.NodeSelectorTerms[0].MatchExpressions {
+		if e.Key == hostnameLabel && e.Operator == apiv1.NodeSelectorOpIn && len(e.Values) == 1 {
+			return e.Values[0]
+		}
+	}
+
+	return ""
+}
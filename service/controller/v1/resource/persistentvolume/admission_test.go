@@ -0,0 +1,239 @@
+package persistentvolume
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Resource_admit(t *testing.T) {
+	one := 1
+	two := 2
+	testCases := []struct {
+		description          string
+		policy               *policy.Policy
+		rules                []policy.Rule
+		cleanupPolicies      map[string]string
+		held                 string
+		volume               string
+		expectedRecycleState string
+	}{
+		{
+			description:          "volume within limits is admitted",
+			policy:               &policy.Policy{},
+			volume:               "queued-standard",
+			expectedRecycleState: cleaning,
+		},
+		{
+			description: "volume queued later waits for volume queued earlier",
+			policy: &policy.Policy{
+				Concurrency: policy.Concurrency{Max: 3},
+			},
+			volume:               "queued-standard",
+			expectedRecycleState: string(recycle.Queued),
+		},
+		{
+			description: "volume queued earlier is admitted first",
+			policy: &policy.Policy{
+				Concurrency: policy.Concurrency{Max: 3},
+			},
+			volume:               "queued-local",
+			expectedRecycleState: cleaning,
+		},
+		{
+			description: "volume exceeding storage class limit does not block other storage classes",
+			policy: &policy.Policy{
+				Concurrency: policy.Concurrency{Max: 3},
+				StorageClasses: map[string]policy.Class{
+					"local-storage": {MaxConcurrent: &two},
+				},
+			},
+			volume:               "queued-standard",
+			expectedRecycleState: cleaning,
+		},
+		{
+			description: "volume exceeding storage class limit stays queued",
+			policy: &policy.Policy{
+				StorageClasses: map[string]policy.Class{
+					"local-storage": {MaxConcurrent: &two},
+				},
+			},
+			volume:               "queued-local",
+			expectedRecycleState: string(recycle.Queued),
+		},
+		{
+			description: "volume exceeding cleanup policy limit stays queued across storage classes",
+			policy:      &policy.Policy{},
+			rules: []policy.Rule{
+				{Name: "databases", Class: policy.Class{MaxConcurrent: &two}},
+			},
+			cleanupPolicies: map[string]string{
+				"cleaning-local-1": "databases",
+				"teardown-local-2": "databases",
+				"queued-standard":  "databases",
+			},
+			volume:               "queued-standard",
+			expectedRecycleState: string(recycle.Queued),
+		},
+		{
+			description: "volume of cleanup policy is not counted against its storage class",
+			policy:      &policy.Policy{},
+			rules: []policy.Rule{
+				{Name: "databases", Class: policy.Class{MaxConcurrent: &one}},
+			},
+			cleanupPolicies: map[string]string{
+				"queued-local": "databases",
+			},
+			volume:               "queued-local",
+			expectedRecycleState: cleaning,
+		},
+		{
+			description: "volume exceeding node limit stays queued",
+			policy: &policy.Policy{
+				Concurrency: policy.Concurrency{MaxPerNode: 1},
+			},
+			volume:               "queued-local",
+			expectedRecycleState: string(recycle.Queued),
+		},
+		{
+			description:          "held volume stays queued",
+			policy:               &policy.Policy{},
+			held:                 "queued-local",
+			volume:               "queued-local",
+			expectedRecycleState: string(recycle.Queued),
+		},
+		{
+			description: "held volume queued earlier does not block volume queued later",
+			policy: &policy.Policy{
+				Concurrency: policy.Concurrency{Max: 3},
+			},
+			held:                 "queued-local",
+			volume:               "queued-standard",
+			expectedRecycleState: cleaning,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			now := time.Now()
+			objects := []runtime.Object{
+				newAdmissionVolume("cleaning-local-1", "local-storage", "worker-1", cleaning, now.Add(-time.Hour)),
+				newAdmissionVolume("teardown-local-2", "local-storage", "worker-2", teardown, now.Add(-time.Hour)),
+				newAdmissionVolume("queued-local", "local-storage", "worker-1", string(recycle.Queued), now.Add(-2*time.Minute)),
+				newAdmissionVolume("queued-standard", "standard", "", string(recycle.Queued), now.Add(-time.Minute)),
+				newAdmissionVolume("recycled-standard", "standard", "", recycled, now.Add(-time.Hour)),
+			}
+			for _, o := range objects {
+				pv := o.(*apiv1.PersistentVolume)
+				if pv.Name == tc.held {
+					pv.Annotations[holdAnnotation] = "true"
+				}
+				if name, ok := tc.cleanupPolicies[pv.Name]; ok {
+					pv.Annotations[cleanupPolicyAnnotation] = name
+				}
+			}
+			tc.policy.SetRules(tc.rules)
+			k8sClient := fake.NewSimpleClientset(objects...)
+
+			var err error
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					Cleaners:      cleaner.Builtin(),
+					CtrlClient:    newCtrlClient(),
+					EventRecorder: &record.FakeRecorder{},
+					K8sClient:     k8sClient,
+					Logger:        microloggertest.New(),
+					Namespace:     metav1.NamespaceSystem,
+					Policy:        tc.policy,
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			pv, err := k8sClient.CoreV1().PersistentVolumes().Get(tc.volume, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+
+			transition, err := recycle.NextTransition(pv.Status.Phase, recycle.Queued)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting transition: %s\n", i+1, err)
+			}
+
+			err = newResource.admit(context.TODO(), pv, transition)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned admitting volume: %s\n", i+1, err)
+			}
+
+			result, err := k8sClient.CoreV1().PersistentVolumes().Get(tc.volume, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+			if result.Annotations[recycleStateAnnotation] != tc.expectedRecycleState {
+				t.Fatalf("case %d expected recycle state %#q got %#q", i+1, tc.expectedRecycleState, result.Annotations[recycleStateAnnotation])
+			}
+			if result.Labels[slotLabel] != slotLabelValue {
+				t.Fatalf("case %d expected slot label %#q got %#q", i+1, slotLabelValue, result.Labels[slotLabel])
+			}
+		})
+	}
+}
+
+func newAdmissionVolume(name, storageClass, node, recycleState string, changedAt time.Time) *apiv1.PersistentVolume {
+	pv := &apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				recycleStateAnnotation:   recycleState,
+				stateChangedAtAnnotation: changedAt.UTC().Format(time.RFC3339),
+			},
+		},
+		Spec: apiv1.PersistentVolumeSpec{
+			ClaimRef: &apiv1.ObjectReference{
+				Namespace: "default",
+				Name:      "data-" + name,
+			},
+			StorageClassName: storageClass,
+		},
+		Status: apiv1.PersistentVolumeStatus{
+			Phase: apiv1.VolumeReleased,
+		},
+	}
+
+	setSlotLabel(pv)
+
+	if node != "" {
+		pv.Spec.Local = &apiv1.LocalVolumeSource{Path: "/mnt/disks/ssd1"}
+		pv.Spec.NodeAffinity = &apiv1.VolumeNodeAffinity{
+			Required: &apiv1.NodeSelector{
+				NodeSelectorTerms: []apiv1.NodeSelectorTerm{
+					{
+						MatchExpressions: []apiv1.NodeSelectorRequirement{
+							{
+								Key:      "kubernetes.io/hostname",
+								Operator: apiv1.NodeSelectorOpIn,
+								Values:   []string{node},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return pv
+}
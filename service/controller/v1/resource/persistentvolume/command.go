@@ -0,0 +1,83 @@
+package persistentvolume
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+const (
+	commandAppliedReason  = "CommandApplied"
+	commandRejectedReason = "CommandRejected"
+)
+
+// applyCommand applies the command an administrator requested for the
+// volume. The command is checked against the current phase and recycle state
+// of the volume again, since the volume may have changed since the command
+// was requested. Rejected commands are dropped. It returns true if the volume
+// had a command.
+//
+// The requester is taken from an annotation which anyone allowed to update
+// the volume can write, so it is reported as unverified. The admin endpoint
+// logs the authenticated caller when it accepts a command.
+func (r *Resource) applyCommand(ctx context.Context, pv *apiv1.PersistentVolume) (bool, error) {
+	command := recycle.Command(pv.Annotations[recycle.CommandAnnotation])
+	if command == "" {
+		return false, nil
+	}
+	requestedBy := pv.Annotations[recycle.CommandRequestedByAnnotation]
+
+	updatedpv := pv.DeepCopy()
+	delete(updatedpv.Annotations, recycle.CommandAnnotation)
+	delete(updatedpv.Annotations, recycle.CommandRequestedByAnnotation)
+
+	state := recycle.State(getVolumeAnnotation(pv, recycleStateAnnotation))
+	err := recycle.CheckCommand(pv.Status.Phase, state, command)
+	if recycle.IsCommandNotAllowed(err) || recycle.IsUnknownCommand(err) {
+		_, err = r.k8sClient.CoreV1().PersistentVolumes().Update(updatedpv)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "warning", "persistentvolume", pv.Name, "unverifiedUser", requestedBy, "message", fmt.Sprintf("rejected command %#q in phase %#q with recycle state %#q", command, pv.Status.Phase, state))
+		r.eventRecorder.Eventf(pv, apiv1.EventTypeWarning, commandRejectedReason, "rejected command %#q requested by %#q (unverified) in phase %#q with recycle state %#q", command, requestedBy, pv.Status.Phase, state)
+
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	var next recycle.State
+	switch command {
+	case recycle.Recycle:
+		resetRecycleAnnotations(updatedpv)
+		updatedpv.Annotations[recycleStartedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		next = recycle.Queued
+	case recycle.Retry:
+		delete(updatedpv.Annotations, retriesAnnotation)
+		delete(updatedpv.Annotations, failureReasonAnnotation)
+		delete(updatedpv.Annotations, failureMessageAnnotation)
+		next = recycle.Cleaning
+	case recycle.Skip:
+		// Skipped volumes were not scrubbed, so no recycle duration is
+		// recorded for them.
+		resetRecycleAnnotations(updatedpv)
+		delete(updatedpv.Annotations, recycleStartedAtAnnotation)
+		next = recycle.Recycled
+	}
+
+	err = r.updateRecycleState(ctx, updatedpv, next)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "info", "persistentvolume", pv.Name, "unverifiedUser", requestedBy, "message", fmt.Sprintf("applied command %#q", command))
+	r.eventRecorder.Eventf(pv, apiv1.EventTypeNormal, commandAppliedReason, "applied command %#q requested by %#q (unverified)", command, requestedBy)
+
+	return true, nil
+}
@@ -0,0 +1,231 @@
+package persistentvolume
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Resource_applyCommand(t *testing.T) {
+	testCases := []struct {
+		description             string
+		phase                   apiv1.PersistentVolumePhase
+		recycleState            string
+		command                 recycle.Command
+		expectedHandled         bool
+		expectedRecycleState    string
+		expectedRetries         string
+		expectedClaimRefPresent bool
+	}{
+		{
+			description:             "volume without command is left alone",
+			phase:                   apiv1.VolumeReleased,
+			recycleState:            string(recycle.Failed),
+			command:                 "",
+			expectedHandled:         false,
+			expectedRecycleState:    string(recycle.Failed),
+			expectedRetries:         "3",
+			expectedClaimRefPresent: true,
+		},
+		{
+			description:             "recycled volume is queued right away",
+			phase:                   apiv1.VolumeReleased,
+			recycleState:            recycled,
+			command:                 recycle.Recycle,
+			expectedHandled:         true,
+			expectedRecycleState:    string(recycle.Queued),
+			expectedRetries:         "",
+			expectedClaimRefPresent: true,
+		},
+		{
+			description:             "failed volume is retried",
+			phase:                   apiv1.VolumeReleased,
+			recycleState:            string(recycle.Failed),
+			command:                 recycle.Retry,
+			expectedHandled:         true,
+			expectedRecycleState:    cleaning,
+			expectedRetries:         "",
+			expectedClaimRefPresent: false,
+		},
+		{
+			description:             "failed volume is marked as recycled",
+			phase:                   apiv1.VolumeReleased,
+			recycleState:            string(recycle.Failed),
+			command:                 recycle.Skip,
+			expectedHandled:         true,
+			expectedRecycleState:    recycled,
+			expectedRetries:         "",
+			expectedClaimRefPresent: false,
+		},
+		{
+			description:             "volume in unknown recycle state is marked as recycled",
+			phase:                   apiv1.VolumeReleased,
+			recycleState:            "Scrubbing",
+			command:                 recycle.Skip,
+			expectedHandled:         true,
+			expectedRecycleState:    recycled,
+			expectedRetries:         "",
+			expectedClaimRefPresent: false,
+		},
+		{
+			description:             "volume being cleaned rejects command",
+			phase:                   apiv1.VolumeBound,
+			recycleState:            cleaning,
+			command:                 recycle.Skip,
+			expectedHandled:         true,
+			expectedRecycleState:    cleaning,
+			expectedRetries:         "3",
+			expectedClaimRefPresent: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume",
+					Annotations: map[string]string{
+						recycleStateAnnotation:               tc.recycleState,
+						retriesAnnotation:                    "3",
+						recycle.CommandRequestedByAnnotation: "jane@example.com",
+					},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					ClaimRef: &apiv1.ObjectReference{
+						Namespace: "default",
+						Name:      "data",
+					},
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: tc.phase,
+				},
+			}
+			if tc.command != "" {
+				pv.Annotations[recycle.CommandAnnotation] = string(tc.command)
+			}
+
+			k8sClient := fake.NewSimpleClientset(pv)
+
+			var err error
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					Cleaners:      cleaner.Builtin(),
+					CtrlClient:    newCtrlClient(),
+					EventRecorder: &record.FakeRecorder{},
+					K8sClient:     k8sClient,
+					Logger:        microloggertest.New(),
+					Namespace:     metav1.NamespaceSystem,
+					Policy:        &policy.Policy{},
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			handled, err := newResource.applyCommand(context.TODO(), pv)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned applying command: %s\n", i+1, err)
+			}
+			if handled != tc.expectedHandled {
+				t.Fatalf("case %d expected handled to be %t got %t", i+1, tc.expectedHandled, handled)
+			}
+
+			result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+			if result.Annotations[recycleStateAnnotation] != tc.expectedRecycleState {
+				t.Fatalf("case %d expected recycle state %#q got %#q", i+1, tc.expectedRecycleState, result.Annotations[recycleStateAnnotation])
+			}
+			if result.Annotations[retriesAnnotation] != tc.expectedRetries {
+				t.Fatalf("case %d expected retries %#q got %#q", i+1, tc.expectedRetries, result.Annotations[retriesAnnotation])
+			}
+			if (result.Spec.ClaimRef != nil) != tc.expectedClaimRefPresent {
+				t.Fatalf("case %d expected claim reference present to be %t got %#v", i+1, tc.expectedClaimRefPresent, result.Spec.ClaimRef)
+			}
+			if tc.expectedHandled {
+				if _, ok := result.Annotations[recycle.CommandAnnotation]; ok {
+					t.Fatalf("case %d expected command annotation to be removed", i+1)
+				}
+			}
+		})
+	}
+}
+
+func Test_Resource_ApplyUpdateChange_CommandInUnknownState(t *testing.T) {
+	pv := &apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "TestPersistentVolume",
+			Annotations: map[string]string{
+				recycleStateAnnotation:               "Scrubbing",
+				recycle.CommandAnnotation:            string(recycle.Skip),
+				recycle.CommandRequestedByAnnotation: "jane@example.com",
+			},
+		},
+		Spec: apiv1.PersistentVolumeSpec{
+			ClaimRef: &apiv1.ObjectReference{
+				Namespace: "default",
+				Name:      "data",
+			},
+		},
+		Status: apiv1.PersistentVolumeStatus{
+			Phase: apiv1.VolumeReleased,
+		},
+	}
+	k8sClient := fake.NewSimpleClientset(pv)
+	eventRecorder := record.NewFakeRecorder(10)
+
+	resourceConfig := Config{
+		Cleaners:      cleaner.Builtin(),
+		CtrlClient:    newCtrlClient(),
+		EventRecorder: eventRecorder,
+		K8sClient:     k8sClient,
+		Logger:        microloggertest.New(),
+		Namespace:     metav1.NamespaceSystem,
+		Policy:        &policy.Policy{},
+	}
+	newResource, err := New(resourceConfig)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	updateState := &RecyclePersistentVolume{
+		Name:         pv.Name,
+		State:        pv.Status.Phase,
+		RecycleState: "Scrubbing",
+	}
+	err = newResource.ApplyUpdateChange(context.TODO(), pv, updateState)
+	if err != nil {
+		t.Fatalf("unexpected error returned applying update: %s\n", err)
+	}
+
+	result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error returned getting volume: %s\n", err)
+	}
+	if result.Annotations[recycleStateAnnotation] != recycled {
+		t.Fatalf("expected recycle state %#q got %#q", recycled, result.Annotations[recycleStateAnnotation])
+	}
+
+	expectedEvent := "Normal CommandApplied applied command `skip` requested by `jane@example.com` (unverified)"
+	var events []string
+	for len(eventRecorder.Events) > 0 {
+		event := <-eventRecorder.Events
+		if event == expectedEvent {
+			return
+		}
+		events = append(events, event)
+	}
+	t.Fatalf("expected event %#q got %v", expectedEvent, events)
+}
@@ -0,0 +1,160 @@
+package persistentvolume
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+const (
+	// deadlineExceededReason is the failure reason of volumes which exceeded
+	// the deadline of their recycle state too often.
+	deadlineExceededReason = "DeadlineExceeded"
+)
+
+// volumeDeadline returns the time the given volume may stay in the given
+// recycle state. Zero means the state is not limited.
+func (r *Resource) volumeDeadline(pv *apiv1.PersistentVolume, state recycle.State) time.Duration {
+	return r.volumePolicy(pv).Deadlines[string(state)].Duration
+}
+
+// stateChangedAt returns the time the deadline of the volume is measured
+// from, which is the time of the last recycle state transition or of the last
+// retry, whichever is later. The second return value is false if the volume
+// has no transition time recorded.
+func stateChangedAt(pv *apiv1.PersistentVolume) (time.Time, bool) {
+	changedAt, err := time.Parse(time.RFC3339, getVolumeAnnotation(pv, stateChangedAtAnnotation))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	retriedAt, err := time.Parse(time.RFC3339, getVolumeAnnotation(pv, retriedAtAnnotation))
+	if err == nil && retriedAt.After(changedAt) {
+		return retriedAt, true
+	}
+
+	return changedAt, true
+}
+
+// canStall checks whether the volume may get stuck in the given transition,
+// waiting for the cleanup claim to be bound, for the cleanup job to finish or
+// for the cleanup claim to be released. Other transitions finish on their own,
+// e.g. starting or finishing the cleaning, and queued volumes wait for other
+// volumes to be cleaned.
+func canStall(transition recycle.Transition) bool {
+	switch {
+	case transition.State == recycle.Cleaning:
+		return transition.Action == recycle.CreateClaim || transition.Action == recycle.RunJob
+	case transition.State == recycle.Teardown:
+		return transition.Action == recycle.None
+	}
+
+	return false
+}
+
+// ensureDeadline checks whether the volume exceeded the deadline of its
+// recycle state. Exceeding volumes get the resources blocking them recreated,
+// or are marked as failed once all retries are used up. Only transitions which
+// can stall are limited. It returns true if the volume was handled and the
+// transition must not be applied.
+func (r *Resource) ensureDeadline(ctx context.Context, pv *apiv1.PersistentVolume, transition recycle.Transition) (bool, error) {
+	if !canStall(transition) {
+		return false, nil
+	}
+
+	deadline := r.volumeDeadline(pv, transition.State)
+	if deadline == 0 {
+		return false, nil
+	}
+
+	changedAt, ok := stateChangedAt(pv)
+	if !ok {
+		// Volumes which entered their state before transition times were
+		// recorded start their deadline now.
+		updatedpv := pv.DeepCopy()
+		if updatedpv.Annotations == nil {
+			updatedpv.Annotations = map[string]string{}
+		}
+		updatedpv.Annotations[stateChangedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+
+		_, err := r.k8sClient.CoreV1().PersistentVolumes().Update(updatedpv)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		return true, nil
+	}
+
+	if time.Since(changedAt) < deadline {
+		return false, nil
+	}
+
+	r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "deadline exceeded", fmt.Sprintf("%s since %s", transition.State, changedAt.Format(time.RFC3339)))
+
+	message := fmt.Sprintf("volume exceeded deadline of %s in recycle state %s", deadline, transition.State)
+
+	if volumeRetries(pv) >= r.maxRetries(pv) {
+		err := r.fail(ctx, pv, deadlineExceededReason, message)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		return true, nil
+	}
+
+	err := r.abortScrub(ctx, pv, deadlineExceededReason, message)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	err = r.escalate(ctx, pv, transition)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	_, err = r.retry(ctx, pv)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
+
+// escalate removes whatever keeps the volume in its recycle state, so that it
+// is recreated by the next reconciliation. Cleaning volumes which are bound
+// get their cleanup job and its pods deleted, other cleaning volumes get
+// their cleanup claim deleted. Teardown volumes get the pods still using the
+// cleanup claim force deleted.
+func (r *Resource) escalate(ctx context.Context, pv *apiv1.PersistentVolume, transition recycle.Transition) error {
+	deleteClaim := true
+	options := &metav1.DeleteOptions{}
+
+	switch {
+	case transition.Phase == apiv1.VolumeBound && transition.State == recycle.Cleaning:
+		deleteClaim = false
+	case transition.State == recycle.Teardown:
+		var gracePeriod int64
+		options.GracePeriodSeconds = &gracePeriod
+	}
+
+	err := r.deleteJob(ctx, jobName(pv), options)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if deleteClaim {
+		err = r.k8sClient.CoreV1().PersistentVolumeClaims(r.namespace).Delete(claimName(pv), &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
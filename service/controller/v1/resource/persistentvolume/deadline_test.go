@@ -0,0 +1,304 @@
+package persistentvolume
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Resource_ensureDeadline(t *testing.T) {
+	testCases := []struct {
+		description           string
+		phase                 apiv1.PersistentVolumePhase
+		recycleState          string
+		stateChangedAt        time.Duration
+		retries               string
+		expectedHandled       bool
+		expectedRecycleState  string
+		expectedRetries       string
+		expectedFailureReason string
+		expectedJobRemoved    bool
+		expectedClaimRemoved  bool
+	}{
+		{
+			description:          "volume within deadline is left alone",
+			phase:                apiv1.VolumeBound,
+			recycleState:         cleaning,
+			stateChangedAt:       10 * time.Minute,
+			retries:              "0",
+			expectedHandled:      false,
+			expectedRecycleState: cleaning,
+			expectedRetries:      "0",
+		},
+		{
+			description:          "bound cleaning volume exceeding deadline gets its job recreated",
+			phase:                apiv1.VolumeBound,
+			recycleState:         cleaning,
+			stateChangedAt:       2 * time.Hour,
+			retries:              "0",
+			expectedHandled:      true,
+			expectedRecycleState: cleaning,
+			expectedRetries:      "1",
+			expectedJobRemoved:   true,
+			expectedClaimRemoved: false,
+		},
+		{
+			description:          "available cleaning volume exceeding deadline gets its claim recreated",
+			phase:                apiv1.VolumeAvailable,
+			recycleState:         cleaning,
+			stateChangedAt:       2 * time.Hour,
+			retries:              "0",
+			expectedHandled:      true,
+			expectedRecycleState: cleaning,
+			expectedRetries:      "1",
+			expectedJobRemoved:   true,
+			expectedClaimRemoved: true,
+		},
+		{
+			description:           "teardown volume exceeding deadline without retries left is marked as failed",
+			phase:                 apiv1.VolumeBound,
+			recycleState:          teardown,
+			stateChangedAt:        2 * time.Hour,
+			retries:               "3",
+			expectedHandled:       true,
+			expectedRecycleState:  string(recycle.Failed),
+			expectedRetries:       "3",
+			expectedFailureReason: deadlineExceededReason,
+			expectedJobRemoved:    true,
+			expectedClaimRemoved:  true,
+		},
+		{
+			description:          "released teardown volume exceeding deadline is left to finish recycling",
+			phase:                apiv1.VolumeReleased,
+			recycleState:         teardown,
+			stateChangedAt:       2 * time.Hour,
+			retries:              "0",
+			expectedHandled:      false,
+			expectedRecycleState: teardown,
+			expectedRetries:      "0",
+		},
+		{
+			description:          "available teardown volume exceeding deadline is left to finish recycling",
+			phase:                apiv1.VolumeAvailable,
+			recycleState:         teardown,
+			stateChangedAt:       2 * time.Hour,
+			retries:              "3",
+			expectedHandled:      false,
+			expectedRecycleState: teardown,
+			expectedRetries:      "3",
+		},
+		{
+			description:          "released cleaning volume exceeding deadline is left to start cleaning",
+			phase:                apiv1.VolumeReleased,
+			recycleState:         cleaning,
+			stateChangedAt:       2 * time.Hour,
+			retries:              "0",
+			expectedHandled:      false,
+			expectedRecycleState: cleaning,
+			expectedRetries:      "0",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume",
+					Annotations: map[string]string{
+						recycleStateAnnotation:   tc.recycleState,
+						retriesAnnotation:        tc.retries,
+						stateChangedAtAnnotation: time.Now().Add(-tc.stateChangedAt).UTC().Format(time.RFC3339),
+					},
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: tc.phase,
+				},
+			}
+			pvc := &apiv1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pv-cleaner-claim-TestPersistentVolume",
+					Namespace: metav1.NamespaceSystem,
+				},
+			}
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume",
+					Namespace: metav1.NamespaceSystem,
+				},
+			}
+
+			k8sClient := fake.NewSimpleClientset(pv, pvc, job)
+
+			var err error
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					Cleaners:      cleaner.Builtin(),
+					CtrlClient:    newCtrlClient(),
+					EventRecorder: &record.FakeRecorder{},
+					K8sClient:     k8sClient,
+					Logger:        microloggertest.New(),
+					Namespace:     metav1.NamespaceSystem,
+					Policy: &policy.Policy{
+						Default: policy.Class{
+							Deadlines: map[string]metav1.Duration{
+								cleaning: {Duration: time.Hour},
+								teardown: {Duration: time.Hour},
+							},
+						},
+					},
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			transition, err := recycle.NextTransition(tc.phase, recycle.State(tc.recycleState))
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting transition: %s\n", i+1, err)
+			}
+
+			handled, err := newResource.ensureDeadline(context.TODO(), pv, transition)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned ensuring deadline: %s\n", i+1, err)
+			}
+			if handled != tc.expectedHandled {
+				t.Fatalf("case %d expected handled to be %t got %t", i+1, tc.expectedHandled, handled)
+			}
+
+			result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+
+			if result.Annotations[recycleStateAnnotation] != tc.expectedRecycleState {
+				t.Fatalf("case %d expected recycle state %#q got %#q", i+1, tc.expectedRecycleState, result.Annotations[recycleStateAnnotation])
+			}
+			if result.Annotations[retriesAnnotation] != tc.expectedRetries {
+				t.Fatalf("case %d expected retries %#q got %#q", i+1, tc.expectedRetries, result.Annotations[retriesAnnotation])
+			}
+			if result.Annotations[failureReasonAnnotation] != tc.expectedFailureReason {
+				t.Fatalf("case %d expected failure reason %#q got %#q", i+1, tc.expectedFailureReason, result.Annotations[failureReasonAnnotation])
+			}
+
+			_, err = k8sClient.BatchV1().Jobs(metav1.NamespaceSystem).Get(job.Name, metav1.GetOptions{})
+			if errors.IsNotFound(err) != tc.expectedJobRemoved {
+				t.Fatalf("case %d expected job removed to be %t, got %#v", i+1, tc.expectedJobRemoved, err)
+			}
+
+			_, err = k8sClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceSystem).Get(pvc.Name, metav1.GetOptions{})
+			if errors.IsNotFound(err) != tc.expectedClaimRemoved {
+				t.Fatalf("case %d expected claim removed to be %t, got %#v", i+1, tc.expectedClaimRemoved, err)
+			}
+		})
+	}
+}
+
+func Test_Resource_ApplyUpdateChange_DeadlineExceeded(t *testing.T) {
+	testCases := []struct {
+		description          string
+		recycleState         string
+		expectedRecycleState string
+	}{
+		{
+			description:          "released teardown volume exceeding deadline is recycled",
+			recycleState:         teardown,
+			expectedRecycleState: recycled,
+		},
+		{
+			description:          "released cleaning volume exceeding deadline starts cleaning",
+			recycleState:         cleaning,
+			expectedRecycleState: cleaning,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume",
+					Annotations: map[string]string{
+						recycleStateAnnotation:   tc.recycleState,
+						retriesAnnotation:        "0",
+						stateChangedAtAnnotation: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+					},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					ClaimRef: &apiv1.ObjectReference{
+						Namespace: metav1.NamespaceSystem,
+						Name:      "pv-cleaner-claim-TestPersistentVolume",
+					},
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: apiv1.VolumeReleased,
+				},
+			}
+			k8sClient := fake.NewSimpleClientset(pv)
+
+			var err error
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					Cleaners:      cleaner.Builtin(),
+					CtrlClient:    newCtrlClient(),
+					EventRecorder: &record.FakeRecorder{},
+					K8sClient:     k8sClient,
+					Logger:        microloggertest.New(),
+					Namespace:     metav1.NamespaceSystem,
+					Policy: &policy.Policy{
+						Default: policy.Class{
+							Deadlines: map[string]metav1.Duration{
+								cleaning: {Duration: time.Hour},
+								teardown: {Duration: time.Hour},
+							},
+						},
+					},
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			updateState := &RecyclePersistentVolume{
+				Name:         pv.Name,
+				State:        pv.Status.Phase,
+				RecycleState: tc.recycleState,
+			}
+			err = newResource.ApplyUpdateChange(context.TODO(), pv, updateState)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned applying update: %s\n", i+1, err)
+			}
+
+			result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+			if result.Annotations[recycleStateAnnotation] != tc.expectedRecycleState {
+				t.Fatalf("case %d expected recycle state %#q got %#q", i+1, tc.expectedRecycleState, result.Annotations[recycleStateAnnotation])
+			}
+			if result.Annotations[retriesAnnotation] != "0" {
+				t.Fatalf("case %d expected retries %#q got %#q", i+1, "0", result.Annotations[retriesAnnotation])
+			}
+			if result.Annotations[failureReasonAnnotation] != "" {
+				t.Fatalf("case %d expected no failure reason got %#q", i+1, result.Annotations[failureReasonAnnotation])
+			}
+			if result.Spec.ClaimRef != nil {
+				t.Fatalf("case %d expected no claim reference got %#v", i+1, result.Spec.ClaimRef)
+			}
+		})
+	}
+}
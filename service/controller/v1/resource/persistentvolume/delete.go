@@ -3,15 +3,105 @@ package persistentvolume
 import (
 	"context"
 
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller/context/finalizerskeptcontext"
 	"github.com/giantswarm/operatorkit/resource/crud"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
-// NewDeletePatch returns patch to apply on deleted persistent volume.
+const (
+	// volumeDeletedReason is the failure reason of cleanup attempts of
+	// volumes which were deleted while they were cleaned.
+	volumeDeletedReason = "VolumeDeleted"
+)
+
+// NewDeletePatch returns patch to apply on deleted persistent volume. Only
+// volumes carrying the cleanup finalizer need to be handled.
 func (r *Resource) NewDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*crud.Patch, error) {
-	return nil, nil
+	pv, err := toPV(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if !hasCleanupFinalizer(pv) {
+		return nil, nil
+	}
+
+	patch := crud.NewPatch()
+	patch.SetDeleteChange(pv)
+
+	return patch, nil
 }
 
-// ApplyDeleteChange represents delete patch logic.
+// ApplyDeleteChange represents delete patch logic. The cleanup job, its pods
+// and the cleanup claim of the deleted volume are removed. The cleanup
+// finalizer is removed once all of them are gone, until then the finalizers
+// are kept so that the deletion is processed again.
 func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteState interface{}) error {
+	pv, err := toPV(deleteState)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.abortScrub(ctx, pv, volumeDeletedReason, "volume was deleted before its cleanup finished")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.deleteJob(ctx, jobName(pv), &metav1.DeleteOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.k8sClient.CoreV1().PersistentVolumeClaims(r.namespace).Delete(claimName(pv), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+
+	{
+		podSelector := labels.Set(map[string]string{jobLabel: jobName(pv)})
+		pods, err := r.k8sClient.CoreV1().Pods(r.namespace).List(metav1.ListOptions{LabelSelector: podSelector.AsSelector().String()})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if len(pods.Items) > 0 {
+			r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "waiting for cleanup pods to be removed", len(pods.Items))
+			finalizerskeptcontext.SetKept(ctx)
+			return nil
+		}
+	}
+
+	{
+		_, err := r.k8sClient.CoreV1().PersistentVolumeClaims(r.namespace).Get(claimName(pv), metav1.GetOptions{})
+		if err == nil {
+			r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "waiting for cleanup claim to be removed", claimName(pv))
+			finalizerskeptcontext.SetKept(ctx)
+			return nil
+		} else if !errors.IsNotFound(err) {
+			return microerror.Mask(err)
+		}
+	}
+
+	// The volume is fetched again, since it changed since the deletion was
+	// requested.
+	current, err := r.k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	removeCleanupFinalizer(current)
+
+	_, err = r.k8sClient.CoreV1().PersistentVolumes().Update(current)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "removed cleanup finalizer", cleanupFinalizer)
+
 	return nil
 }
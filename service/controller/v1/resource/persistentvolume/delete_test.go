@@ -0,0 +1,132 @@
+package persistentvolume
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/operatorkit/controller/context/finalizerskeptcontext"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+)
+
+func Test_Resource_ApplyDeleteChange(t *testing.T) {
+	testCases := []struct {
+		description        string
+		claimProtected     bool
+		expectedKept       bool
+		expectedFinalizers []string
+	}{
+		{
+			description:        "cleanup finalizer is removed once cleanup resources are gone",
+			claimProtected:     false,
+			expectedKept:       false,
+			expectedFinalizers: []string{"kubernetes.io/pv-protection"},
+		},
+		{
+			description:        "finalizers are kept while the cleanup claim is still in use",
+			claimProtected:     true,
+			expectedKept:       true,
+			expectedFinalizers: []string{"kubernetes.io/pv-protection", cleanupFinalizer},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			now := metav1.Now()
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "TestPersistentVolume",
+					DeletionTimestamp: &now,
+					Finalizers: []string{
+						"kubernetes.io/pv-protection",
+						cleanupFinalizer,
+					},
+					Annotations: map[string]string{
+						recycleStateAnnotation: cleaning,
+					},
+				},
+			}
+			pvc := &apiv1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pv-cleaner-claim-TestPersistentVolume",
+					Namespace: metav1.NamespaceSystem,
+				},
+			}
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume",
+					Namespace: metav1.NamespaceSystem,
+				},
+			}
+
+			k8sClient := fake.NewSimpleClientset(pv, pvc, job)
+			if tc.claimProtected {
+				// Kubernetes keeps claims which are still in use by pods.
+				k8sClient.PrependReactor("get", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, pvc, nil
+				})
+			}
+
+			var err error
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					Cleaners:      cleaner.Builtin(),
+					CtrlClient:    newCtrlClient(),
+					EventRecorder: &record.FakeRecorder{},
+					K8sClient:     k8sClient,
+					Logger:        microloggertest.New(),
+					Namespace:     metav1.NamespaceSystem,
+					Policy:        &policy.Policy{},
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			ctx := finalizerskeptcontext.NewContext(context.Background(), make(chan struct{}))
+
+			patch, err := newResource.NewDeletePatch(ctx, pv, nil, nil)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned creating delete patch: %s\n", i+1, err)
+			}
+			if patch == nil {
+				t.Fatalf("case %d expected delete patch got nil", i+1)
+			}
+
+			err = newResource.ApplyDeleteChange(ctx, pv, pv)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned applying delete change: %s\n", i+1, err)
+			}
+
+			if finalizerskeptcontext.IsKept(ctx) != tc.expectedKept {
+				t.Fatalf("case %d expected finalizers kept to be %t got %t", i+1, tc.expectedKept, finalizerskeptcontext.IsKept(ctx))
+			}
+
+			_, err = k8sClient.BatchV1().Jobs(metav1.NamespaceSystem).Get(job.Name, metav1.GetOptions{})
+			if !errors.IsNotFound(err) {
+				t.Fatalf("case %d expected job to be removed, got %#v", i+1, err)
+			}
+
+			result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+			if !reflect.DeepEqual(tc.expectedFinalizers, result.Finalizers) {
+				t.Fatalf("case %d expected finalizers %#v got %#v", i+1, tc.expectedFinalizers, result.Finalizers)
+			}
+		})
+	}
+}
@@ -21,3 +21,39 @@ var wrongTypeError = &microerror.Error{
 func IsWrongTypeError(err error) bool {
 	return microerror.Cause(err) == wrongTypeError
 }
+
+var unknownStrategyError = &microerror.Error{
+	Kind: "unknownStrategyError",
+}
+
+// IsUnknownStrategy asserts unknownStrategyError.
+func IsUnknownStrategy(err error) bool {
+	return microerror.Cause(err) == unknownStrategyError
+}
+
+var invalidParametersError = &microerror.Error{
+	Kind: "invalidParametersError",
+}
+
+// IsInvalidParameters asserts invalidParametersError.
+func IsInvalidParameters(err error) bool {
+	return microerror.Cause(err) == invalidParametersError
+}
+
+var invalidPodError = &microerror.Error{
+	Kind: "invalidPodError",
+}
+
+// IsInvalidPod asserts invalidPodError.
+func IsInvalidPod(err error) bool {
+	return microerror.Cause(err) == invalidPodError
+}
+
+var invalidGracePeriodError = &microerror.Error{
+	Kind: "invalidGracePeriodError",
+}
+
+// IsInvalidGracePeriod asserts invalidGracePeriodError.
+func IsInvalidGracePeriod(err error) bool {
+	return microerror.Cause(err) == invalidGracePeriodError
+}
@@ -0,0 +1,71 @@
+package persistentvolume
+
+import (
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+const (
+	claimCreatedReason     = "CleanupClaimCreated"
+	cleanupHeldReason      = "CleanupHeld"
+	cleanupScheduledReason = "CleanupScheduled"
+	claimRestoredReason    = "ClaimRestored"
+	jobFailedReason        = "CleanupJobFailed"
+	jobRetriedReason       = "CleanupJobRetried"
+	jobStartedReason       = "CleanupJobStarted"
+	jobSucceededReason     = "CleanupJobSucceeded"
+	recycleFailedReason    = "RecycleFailed"
+)
+
+// stateEvents are the reasons and messages of the events emitted when a
+// volume enters a recycle state.
+var stateEvents = map[recycle.State]struct {
+	reason  string
+	message string
+}{
+	recycle.Queued:   {reason: "CleanupQueued", message: "queued volume for cleanup"},
+	recycle.Cleaning: {reason: "CleanupStarted", message: "started cleaning volume"},
+	recycle.Teardown: {reason: "CleanupTeardown", message: "removing cleanup claim of volume"},
+	recycle.Recycled: {reason: "Recycled", message: "recycled volume"},
+}
+
+// recordStateEvent emits an event on the volume for entering the given
+// recycle state.
+func (r *Resource) recordStateEvent(pv *apiv1.PersistentVolume, state recycle.State) {
+	e, ok := stateEvents[state]
+	if !ok {
+		return
+	}
+
+	message := e.message
+	if method := getVolumeAnnotation(pv, methodAnnotation); state == recycle.Recycled && method != "" {
+		message = fmt.Sprintf("%s using method %#q", message, method)
+	}
+
+	r.eventRecorder.Event(pv, apiv1.EventTypeNormal, e.reason, message)
+}
+
+// jobDuration returns the time the given job ran for. Jobs which did not
+// finish yet are measured until now.
+func jobDuration(job *batchv1.Job) time.Duration {
+	if job.Status.StartTime == nil {
+		return 0
+	}
+
+	finishedAt := time.Now()
+	if job.Status.CompletionTime != nil {
+		finishedAt = job.Status.CompletionTime.Time
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == apiv1.ConditionTrue && !c.LastTransitionTime.IsZero() {
+			finishedAt = c.LastTransitionTime.Time
+		}
+	}
+
+	return finishedAt.Sub(job.Status.StartTime.Time).Round(time.Second)
+}
@@ -0,0 +1,53 @@
+package persistentvolume
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// cleanupFinalizer protects volumes from being removed while the operator
+	// owns a cleanup claim, cleanup job or cleanup pods for them. It is added
+	// once the volume enters the Cleaning recycle state and removed once the
+	// volume is recycled, or once all cleanup resources are removed after the
+	// volume got deleted.
+	cleanupFinalizer = "pv-cleaner-operator.giantswarm.io/cleanup-protection"
+)
+
+// hasCleanupFinalizer checks whether the given volume carries the cleanup
+// finalizer.
+func hasCleanupFinalizer(pv *apiv1.PersistentVolume) bool {
+	for _, f := range pv.Finalizers {
+		if f == cleanupFinalizer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addCleanupFinalizer adds the cleanup finalizer to the given volume unless
+// it is already present.
+func addCleanupFinalizer(pv *apiv1.PersistentVolume) {
+	if hasCleanupFinalizer(pv) {
+		return
+	}
+
+	pv.Finalizers = append(pv.Finalizers, cleanupFinalizer)
+}
+
+// removeCleanupFinalizer removes the cleanup finalizer from the given volume.
+func removeCleanupFinalizer(pv *apiv1.PersistentVolume) {
+	removeFinalizer(pv, cleanupFinalizer)
+}
+
+// removeFinalizer removes the given finalizer from the given volume.
+func removeFinalizer(pv *apiv1.PersistentVolume, finalizer string) {
+	var finalizers []string
+	for _, f := range pv.Finalizers {
+		if f != finalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+
+	pv.Finalizers = finalizers
+}
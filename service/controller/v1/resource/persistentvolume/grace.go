@@ -0,0 +1,116 @@
+package persistentvolume
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// isHeld checks whether cleaning the given volume is held by the hold
+// annotation.
+func isHeld(pv *apiv1.PersistentVolume) bool {
+	return getVolumeAnnotation(pv, holdAnnotation) == "true"
+}
+
+// volumeGracePeriod returns the time the given volume is kept untouched
+// after it got released. The grace period annotation, in the form of a Go
+// duration like 72h, takes precedence over the policy of the volume.
+func (r *Resource) volumeGracePeriod(pv *apiv1.PersistentVolume) (time.Duration, error) {
+	annotationValue := getVolumeAnnotation(pv, gracePeriodAnnotation)
+	if annotationValue == "" {
+		gracePeriod := r.volumePolicy(pv).GracePeriod
+		if gracePeriod == nil {
+			return 0, nil
+		}
+		return gracePeriod.Duration, nil
+	}
+
+	gracePeriod, err := time.ParseDuration(annotationValue)
+	if err != nil {
+		return 0, microerror.Maskf(invalidGracePeriodError, "persistent volume %#q: %s", pv.Name, err)
+	}
+	if gracePeriod < 0 {
+		return 0, microerror.Maskf(invalidGracePeriodError, "persistent volume %#q: grace period %#q must not be negative", pv.Name, annotationValue)
+	}
+
+	return gracePeriod, nil
+}
+
+// waitGracePeriod keeps the released volume untouched for its grace period,
+// and as long as it is held. The time of the release is recorded in the
+// released at annotation, and the time the cleaning starts in the cleanup
+// after annotation, so that it is visible on the volume. Volumes with an
+// invalid grace period annotation are kept as well, since the error is
+// returned. It returns true if the volume must not be cleaned yet.
+func (r *Resource) waitGracePeriod(ctx context.Context, pv *apiv1.PersistentVolume) (bool, error) {
+	gracePeriod, err := r.volumeGracePeriod(pv)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	held := isHeld(pv)
+	if gracePeriod == 0 && !held {
+		return false, nil
+	}
+
+	releasedAt, err := time.Parse(time.RFC3339, getVolumeAnnotation(pv, releasedAtAnnotation))
+	unrecorded := err != nil
+	if unrecorded {
+		releasedAt = time.Now().UTC().Truncate(time.Second)
+	}
+	cleanupAt := releasedAt.Add(gracePeriod)
+
+	cleanupAfter := cleanupAt.UTC().Format(time.RFC3339)
+	if held {
+		cleanupAfter = ""
+	}
+
+	if !unrecorded && !held && !time.Now().Before(cleanupAt) {
+		return false, nil
+	}
+
+	if unrecorded || getVolumeAnnotation(pv, cleanupAfterAnnotation) != cleanupAfter {
+		updatedpv := pv.DeepCopy()
+		if updatedpv.Annotations == nil {
+			updatedpv.Annotations = map[string]string{}
+		}
+		updatedpv.Annotations[releasedAtAnnotation] = releasedAt.Format(time.RFC3339)
+		// The claim reference is kept while the volume is released, remember
+		// it anyway so that the claim the volume can be restored to stays
+		// visible.
+		if ref := pv.Spec.ClaimRef; ref != nil {
+			updatedpv.Annotations[previousClaimAnnotation] = fmt.Sprintf("%s/%s", ref.Namespace, ref.Name)
+		}
+		if held {
+			delete(updatedpv.Annotations, cleanupAfterAnnotation)
+		} else {
+			updatedpv.Annotations[cleanupAfterAnnotation] = cleanupAfter
+		}
+
+		_, err = r.k8sClient.CoreV1().PersistentVolumes().Update(updatedpv)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		if held {
+			r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "holding released volume", holdAnnotation)
+			r.eventRecorder.Eventf(pv, apiv1.EventTypeNormal, cleanupHeldReason, "holding released volume until annotation %#q is removed", holdAnnotation)
+		} else {
+			r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "keeping released volume until", cleanupAfter)
+			r.eventRecorder.Eventf(pv, apiv1.EventTypeNormal, cleanupScheduledReason, "cleaning released volume after %s, grace period is %s", cleanupAfter, gracePeriod)
+		}
+
+		return true, nil
+	}
+
+	if held {
+		r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "holding released volume", holdAnnotation)
+	} else {
+		r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "waiting for grace period to pass", cleanupAfter)
+	}
+
+	return true, nil
+}
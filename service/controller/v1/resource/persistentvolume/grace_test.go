@@ -0,0 +1,158 @@
+package persistentvolume
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Resource_startCleaning_GracePeriod(t *testing.T) {
+	releasedAt := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Second)
+
+	testCases := []struct {
+		description          string
+		gracePeriod          time.Duration
+		annotations          map[string]string
+		expectedRecycleState string
+		expectedCleanupAfter string
+		errorMatcher         func(error) bool
+	}{
+		{
+			description:          "volume without grace period is queued",
+			annotations:          map[string]string{},
+			expectedRecycleState: string(recycle.Queued),
+		},
+		{
+			description: "volume within grace period of policy is kept",
+			gracePeriod: 3 * time.Hour,
+			annotations: map[string]string{
+				releasedAtAnnotation: releasedAt.Format(time.RFC3339),
+			},
+			expectedRecycleState: "",
+			expectedCleanupAfter: releasedAt.Add(3 * time.Hour).Format(time.RFC3339),
+		},
+		{
+			description: "grace period annotation takes precedence over policy",
+			gracePeriod: 3 * time.Hour,
+			annotations: map[string]string{
+				gracePeriodAnnotation: "1h",
+				releasedAtAnnotation:  releasedAt.Format(time.RFC3339),
+			},
+			expectedRecycleState: string(recycle.Queued),
+		},
+		{
+			description: "volume within grace period of annotation is kept",
+			annotations: map[string]string{
+				gracePeriodAnnotation: "72h",
+				releasedAtAnnotation:  releasedAt.Format(time.RFC3339),
+			},
+			expectedRecycleState: "",
+			expectedCleanupAfter: releasedAt.Add(72 * time.Hour).Format(time.RFC3339),
+		},
+		{
+			description: "held volume is kept after grace period",
+			gracePeriod: time.Hour,
+			annotations: map[string]string{
+				cleanupAfterAnnotation: releasedAt.Add(time.Hour).Format(time.RFC3339),
+				holdAnnotation:         "true",
+				releasedAtAnnotation:   releasedAt.Format(time.RFC3339),
+			},
+			expectedRecycleState: "",
+			expectedCleanupAfter: "",
+		},
+		{
+			description: "held volume without grace period is kept",
+			annotations: map[string]string{
+				holdAnnotation: "true",
+			},
+			expectedRecycleState: "",
+			expectedCleanupAfter: "",
+		},
+		{
+			description: "volume with invalid grace period annotation is kept",
+			annotations: map[string]string{
+				gracePeriodAnnotation: "three days",
+			},
+			expectedRecycleState: "",
+			errorMatcher:         IsInvalidGracePeriod,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "TestPersistentVolume",
+					Annotations: tc.annotations,
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					ClaimRef: &apiv1.ObjectReference{
+						Namespace: "default",
+						Name:      "data",
+					},
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: apiv1.VolumeReleased,
+				},
+			}
+			k8sClient := fake.NewSimpleClientset(pv)
+
+			var err error
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					Cleaners:      cleaner.Builtin(),
+					CtrlClient:    newCtrlClient(),
+					EventRecorder: &record.FakeRecorder{},
+					K8sClient:     k8sClient,
+					Logger:        microloggertest.New(),
+					Namespace:     metav1.NamespaceSystem,
+					Policy: &policy.Policy{
+						Default: policy.Class{
+							GracePeriod: &metav1.Duration{Duration: tc.gracePeriod},
+						},
+					},
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			transition, err := recycle.NextTransition(apiv1.VolumeReleased, recycle.Recycled)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting transition: %s\n", i+1, err)
+			}
+
+			err = newResource.startCleaning(context.TODO(), pv.DeepCopy(), transition)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("case %d expected error matcher to match, got %#v", i+1, err)
+				}
+			} else if err != nil {
+				t.Fatalf("case %d unexpected error returned starting cleaning: %s\n", i+1, err)
+			}
+
+			result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+			if result.Annotations[recycleStateAnnotation] != tc.expectedRecycleState {
+				t.Fatalf("case %d expected recycle state %#q got %#q", i+1, tc.expectedRecycleState, result.Annotations[recycleStateAnnotation])
+			}
+			if tc.expectedRecycleState == "" && result.Annotations[cleanupAfterAnnotation] != tc.expectedCleanupAfter {
+				t.Fatalf("case %d expected cleanup after %#q got %#q", i+1, tc.expectedCleanupAfter, result.Annotations[cleanupAfterAnnotation])
+			}
+		})
+	}
+}
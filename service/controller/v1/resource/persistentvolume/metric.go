@@ -0,0 +1,119 @@
+package persistentvolume
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+)
+
+const (
+	PrometheusNamespace = "pv_cleaner_operator"
+	PrometheusSubsystem = "recycle"
+)
+
+var (
+	// durationBuckets range from 30 seconds to about 17 hours.
+	durationBuckets = prometheus.ExponentialBuckets(30, 2, 12)
+
+	cleanupStartedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: PrometheusSubsystem,
+			Name:      "cleanup_started_total",
+			Help:      "Number of started cleanup jobs.",
+		},
+		[]string{"storage_class"},
+	)
+
+	cleanupSucceededCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: PrometheusSubsystem,
+			Name:      "cleanup_succeeded_total",
+			Help:      "Number of succeeded cleanup jobs.",
+		},
+		[]string{"storage_class"},
+	)
+
+	cleanupFailedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: PrometheusSubsystem,
+			Name:      "cleanup_failed_total",
+			Help:      "Number of failed cleanup jobs.",
+		},
+		[]string{"storage_class"},
+	)
+
+	reclaimedBytesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: PrometheusSubsystem,
+			Name:      "reclaimed_bytes_total",
+			Help:      "Number of bytes reclaimed by cleanup jobs which measure them.",
+		},
+		[]string{"storage_class"},
+	)
+
+	recycleDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: PrometheusSubsystem,
+			Name:      "duration_seconds",
+			Help:      "Time taken from the release of a volume until it is recycled.",
+			Buckets:   durationBuckets,
+		},
+		[]string{"storage_class"},
+	)
+
+	stateDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: PrometheusSubsystem,
+			Name:      "state_duration_seconds",
+			Help:      "Time volumes spent in a recycle state before leaving it.",
+			Buckets:   durationBuckets,
+		},
+		[]string{"state"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(cleanupStartedCounter)
+	prometheus.MustRegister(cleanupSucceededCounter)
+	prometheus.MustRegister(cleanupFailedCounter)
+	prometheus.MustRegister(reclaimedBytesCounter)
+	prometheus.MustRegister(recycleDurationHistogram)
+	prometheus.MustRegister(stateDurationHistogram)
+}
+
+// observeStateDuration records the time the volume spent in its current
+// recycle state, which it is about to leave. The time recycled volumes are in
+// use is not recorded.
+func observeStateDuration(pv *apiv1.PersistentVolume) {
+	state := getVolumeAnnotation(pv, recycleStateAnnotation)
+	if state == recycled {
+		return
+	}
+
+	changedAt, err := time.Parse(time.RFC3339, getVolumeAnnotation(pv, stateChangedAtAnnotation))
+	if err != nil {
+		return
+	}
+
+	stateDurationHistogram.WithLabelValues(state).Observe(time.Since(changedAt).Seconds())
+}
+
+// observeRecycleDuration records the time it took to recycle the volume since
+// it was released.
+func observeRecycleDuration(pv *apiv1.PersistentVolume) {
+	startedAt, err := time.Parse(time.RFC3339, getVolumeAnnotation(pv, recycleStartedAtAnnotation))
+	if err != nil {
+		return
+	}
+
+	recycleDurationHistogram.WithLabelValues(policy.StorageClass(pv)).Observe(time.Since(startedAt).Seconds())
+}
@@ -0,0 +1,154 @@
+package persistentvolume
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const (
+	// nodeNotFoundReason is the failure reason of node-local volumes whose
+	// node does not exist anymore.
+	nodeNotFoundReason = "NodeNotFound"
+)
+
+// nodeOperators maps node selector operators to label selector operators.
+var nodeOperators = map[apiv1.NodeSelectorOperator]selection.Operator{
+	apiv1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	apiv1.NodeSelectorOpExists:       selection.Exists,
+	apiv1.NodeSelectorOpGt:           selection.GreaterThan,
+	apiv1.NodeSelectorOpIn:           selection.In,
+	apiv1.NodeSelectorOpLt:           selection.LessThan,
+	apiv1.NodeSelectorOpNotIn:        selection.NotIn,
+}
+
+// isNodeLocal checks whether the given volume is stored on a single node and
+// can only be cleaned there.
+func isNodeLocal(pv *apiv1.PersistentVolume) bool {
+	return pv.Spec.Local != nil || pv.Spec.HostPath != nil
+}
+
+// volumeNodeSelector returns the node selector of the given volume, or nil if
+// the volume is not node-local or not pinned to any node.
+func volumeNodeSelector(pv *apiv1.PersistentVolume) *apiv1.NodeSelector {
+	if !isNodeLocal(pv) {
+		return nil
+	}
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return nil
+	}
+	if len(pv.Spec.NodeAffinity.Required.NodeSelectorTerms) == 0 {
+		return nil
+	}
+
+	return pv.Spec.NodeAffinity.Required
+}
+
+// pinToNode returns the given pod affinity restricted to the nodes of the
+// given node selector. Since node selector terms are ORed and the expressions
+// of a term are ANDed, every term of the affinity is combined with every term
+// of the node selector.
+func pinToNode(affinity *apiv1.Affinity, nodeSelector *apiv1.NodeSelector) *apiv1.Affinity {
+	if nodeSelector == nil {
+		return affinity
+	}
+
+	var pinned *apiv1.Affinity
+	if affinity == nil {
+		pinned = &apiv1.Affinity{}
+	} else {
+		pinned = affinity.DeepCopy()
+	}
+	if pinned.NodeAffinity == nil {
+		pinned.NodeAffinity = &apiv1.NodeAffinity{}
+	}
+
+	required := pinned.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil || len(required.NodeSelectorTerms) == 0 {
+		pinned.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nodeSelector.DeepCopy()
+		return pinned
+	}
+
+	var terms []apiv1.NodeSelectorTerm
+	for _, a := range required.NodeSelectorTerms {
+		for _, b := range nodeSelector.NodeSelectorTerms {
+			t := apiv1.NodeSelectorTerm{}
+			t.MatchExpressions = append(t.MatchExpressions, a.MatchExpressions...)
+			t.MatchExpressions = append(t.MatchExpressions, b.MatchExpressions...)
+			t.MatchFields = append(t.MatchFields, a.MatchFields...)
+			t.MatchFields = append(t.MatchFields, b.MatchFields...)
+			terms = append(terms, t)
+		}
+	}
+	pinned.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &apiv1.NodeSelector{
+		NodeSelectorTerms: terms,
+	}
+
+	return pinned
+}
+
+// nodeExists checks whether any node matches the node selector of the given
+// node-local volume. Volumes which are not pinned to a node, as well as
+// selector terms matching node fields, are assumed to have a node.
+func (r *Resource) nodeExists(pv *apiv1.PersistentVolume) (bool, error) {
+	nodeSelector := volumeNodeSelector(pv)
+	if nodeSelector == nil {
+		return true, nil
+	}
+
+	for _, term := range nodeSelector.NodeSelectorTerms {
+		if len(term.MatchFields) > 0 {
+			return true, nil
+		}
+
+		selector := labels.NewSelector()
+		for _, e := range term.MatchExpressions {
+			op, ok := nodeOperators[e.Operator]
+			if !ok {
+				return true, nil
+			}
+
+			requirement, err := labels.NewRequirement(e.Key, op, e.Values)
+			if err != nil {
+				return false, microerror.Mask(err)
+			}
+			selector = selector.Add(*requirement)
+		}
+
+		nodes, err := r.k8sClient.CoreV1().Nodes().List(metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+		if len(nodes.Items) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ensureNode marks node-local volumes as failed when their node does not
+// exist anymore, since they can not be cleaned. It returns true if the
+// volume was marked as failed.
+func (r *Resource) ensureNode(ctx context.Context, pv *apiv1.PersistentVolume) (bool, error) {
+	exists, err := r.nodeExists(pv)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	message := fmt.Sprintf("no node matches the node affinity of node-local volume %#q", pv.Name)
+	err = r.fail(ctx, pv, nodeNotFoundReason, message)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,202 @@
+package persistentvolume
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Resource_pinToNode(t *testing.T) {
+	hostname := apiv1.NodeSelectorRequirement{
+		Key:      "kubernetes.io/hostname",
+		Operator: apiv1.NodeSelectorOpIn,
+		Values:   []string{"worker-1"},
+	}
+	storage := apiv1.NodeSelectorRequirement{
+		Key:      "node.giantswarm.io/storage",
+		Operator: apiv1.NodeSelectorOpExists,
+	}
+	nodeSelector := &apiv1.NodeSelector{
+		NodeSelectorTerms: []apiv1.NodeSelectorTerm{
+			{MatchExpressions: []apiv1.NodeSelectorRequirement{hostname}},
+		},
+	}
+
+	testCases := []struct {
+		description      string
+		affinity         *apiv1.Affinity
+		nodeSelector     *apiv1.NodeSelector
+		expectedAffinity *apiv1.Affinity
+	}{
+		{
+			description:      "volume without node selector keeps affinity",
+			affinity:         nil,
+			nodeSelector:     nil,
+			expectedAffinity: nil,
+		},
+		{
+			description:  "pod without affinity gets node selector of volume",
+			affinity:     nil,
+			nodeSelector: nodeSelector,
+			expectedAffinity: &apiv1.Affinity{
+				NodeAffinity: &apiv1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: nodeSelector,
+				},
+			},
+		},
+		{
+			description: "pod affinity is combined with node selector of volume",
+			affinity: &apiv1.Affinity{
+				NodeAffinity: &apiv1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &apiv1.NodeSelector{
+						NodeSelectorTerms: []apiv1.NodeSelectorTerm{
+							{MatchExpressions: []apiv1.NodeSelectorRequirement{storage}},
+						},
+					},
+				},
+			},
+			nodeSelector: nodeSelector,
+			expectedAffinity: &apiv1.Affinity{
+				NodeAffinity: &apiv1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &apiv1.NodeSelector{
+						NodeSelectorTerms: []apiv1.NodeSelectorTerm{
+							{MatchExpressions: []apiv1.NodeSelectorRequirement{storage, hostname}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			result := pinToNode(tc.affinity, tc.nodeSelector)
+			if !reflect.DeepEqual(tc.expectedAffinity, result) {
+				t.Fatalf("case %d expected %#v got %#v", i+1, tc.expectedAffinity, result)
+			}
+		})
+	}
+}
+
+func Test_Resource_ensureNode(t *testing.T) {
+	testCases := []struct {
+		description          string
+		source               apiv1.PersistentVolumeSource
+		node                 string
+		expectedHandled      bool
+		expectedRecycleState string
+	}{
+		{
+			description: "local volume with existing node is cleaned",
+			source: apiv1.PersistentVolumeSource{
+				Local: &apiv1.LocalVolumeSource{Path: "/mnt/disks/ssd1"},
+			},
+			node:                 "worker-1",
+			expectedHandled:      false,
+			expectedRecycleState: cleaning,
+		},
+		{
+			description: "local volume without node is marked as failed",
+			source: apiv1.PersistentVolumeSource{
+				Local: &apiv1.LocalVolumeSource{Path: "/mnt/disks/ssd1"},
+			},
+			node:                 "worker-2",
+			expectedHandled:      true,
+			expectedRecycleState: string(recycle.Failed),
+		},
+		{
+			description: "network volume is not checked",
+			source: apiv1.PersistentVolumeSource{
+				NFS: &apiv1.NFSVolumeSource{Server: "nfs.example.com", Path: "/exports"},
+			},
+			node:                 "worker-2",
+			expectedHandled:      false,
+			expectedRecycleState: cleaning,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume",
+					Annotations: map[string]string{
+						recycleStateAnnotation: cleaning,
+					},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					NodeAffinity: &apiv1.VolumeNodeAffinity{
+						Required: &apiv1.NodeSelector{
+							NodeSelectorTerms: []apiv1.NodeSelectorTerm{
+								{
+									MatchExpressions: []apiv1.NodeSelectorRequirement{
+										{
+											Key:      "kubernetes.io/hostname",
+											Operator: apiv1.NodeSelectorOpIn,
+											Values:   []string{tc.node},
+										},
+									},
+								},
+							},
+						},
+					},
+					PersistentVolumeSource: tc.source,
+				},
+			}
+			node := &apiv1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "worker-1",
+					Labels: map[string]string{
+						"kubernetes.io/hostname": "worker-1",
+					},
+				},
+			}
+
+			k8sClient := fake.NewSimpleClientset(pv, node)
+
+			var err error
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					Cleaners:      cleaner.Builtin(),
+					CtrlClient:    newCtrlClient(),
+					EventRecorder: &record.FakeRecorder{},
+					K8sClient:     k8sClient,
+					Logger:        microloggertest.New(),
+					Namespace:     metav1.NamespaceSystem,
+					Policy:        &policy.Policy{},
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			handled, err := newResource.ensureNode(context.TODO(), pv)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned ensuring node: %s\n", i+1, err)
+			}
+			if handled != tc.expectedHandled {
+				t.Fatalf("case %d expected handled to be %t got %t", i+1, tc.expectedHandled, handled)
+			}
+
+			result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+			if result.Annotations[recycleStateAnnotation] != tc.expectedRecycleState {
+				t.Fatalf("case %d expected recycle state %#q got %#q", i+1, tc.expectedRecycleState, result.Annotations[recycleStateAnnotation])
+			}
+		})
+	}
+}
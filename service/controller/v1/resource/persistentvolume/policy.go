@@ -0,0 +1,85 @@
+package persistentvolume
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+// desiredPolicy returns the name of the cleanup policy which should be
+// recorded on the given volume. Policies are only evaluated for recycled
+// volumes, so that the settings of a volume do not change while it is being
+// cleaned. An empty name means no cleanup policy selects the volume.
+func (r *Resource) desiredPolicy(pv *apiv1.PersistentVolume) string {
+	state := recycle.State(getVolumeAnnotation(pv, recycleStateAnnotation))
+	if state != "" && state != recycle.Recycled {
+		return pv.Annotations[cleanupPolicyAnnotation]
+	}
+
+	rule, ok := r.policy.Match(pv)
+	if !ok {
+		return ""
+	}
+
+	return rule.Name
+}
+
+// ensurePolicy records the cleanup policy selecting the volume. Once no cleanup
+// policy selects a volume without cleanup label anymore, the recorded policy
+// and the finalizer of the controller are removed, so that the volume is no
+// longer handled. It returns true if the volume was updated.
+func (r *Resource) ensurePolicy(ctx context.Context, pv *apiv1.PersistentVolume) (bool, error) {
+	name := r.desiredPolicy(pv)
+	if pv.Annotations[cleanupPolicyAnnotation] == name {
+		return false, nil
+	}
+
+	updatedpv := pv.DeepCopy()
+	if name == "" {
+		delete(updatedpv.Annotations, cleanupPolicyAnnotation)
+		if !isLabeled(updatedpv) {
+			removeFinalizer(updatedpv, r.finalizer)
+		}
+	} else {
+		if updatedpv.Annotations == nil {
+			updatedpv.Annotations = map[string]string{}
+		}
+		updatedpv.Annotations[cleanupPolicyAnnotation] = name
+	}
+
+	_, err := r.k8sClient.CoreV1().PersistentVolumes().Update(updatedpv)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if name == "" {
+		r.logger.LogCtx(ctx, "level", "debug", "persistentvolume", pv.Name, "message", "no cleanup policy selects volume")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "persistentvolume", pv.Name, "message", fmt.Sprintf("cleanup policy %#q selects volume", name))
+	}
+
+	return true, nil
+}
+
+// Handles returns true if the given volume is handled by the operator. These
+// are volumes carrying the cleanup label or selected by a cleanup policy, and
+// volumes which still record a cleanup policy, until it is removed by
+// ensurePolicy.
+func Handles(p *policy.Policy, pv *apiv1.PersistentVolume) bool {
+	if isLabeled(pv) || pv.Annotations[cleanupPolicyAnnotation] != "" {
+		return true
+	}
+
+	_, ok := p.Match(pv)
+	return ok
+}
+
+// isLabeled checks whether the given volume carries the cleanup label.
+func isLabeled(pv *apiv1.PersistentVolume) bool {
+	return pv.Labels[policy.VolumeLabel] == policy.VolumeLabelValue
+}
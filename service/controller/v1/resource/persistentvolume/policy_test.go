@@ -0,0 +1,235 @@
+package persistentvolume
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+)
+
+const (
+	testFinalizer = "operatorkit.giantswarm.io/pv-cleaner-operator"
+)
+
+func Test_Resource_ensurePolicy(t *testing.T) {
+	testCases := []struct {
+		description        string
+		labels             map[string]string
+		annotations        map[string]string
+		claimNamespace     string
+		expectedUpdated    bool
+		expectedPolicy     string
+		expectedFinalizers []string
+		expectedHandled    bool
+	}{
+		{
+			description:        "volume selected by cleanup policy records it without cleanup label",
+			claimNamespace:     "tenant",
+			expectedUpdated:    true,
+			expectedPolicy:     "tenant",
+			expectedFinalizers: []string{"kubernetes.io/pv-protection", testFinalizer},
+			expectedHandled:    true,
+		},
+		{
+			description: "volume with recorded cleanup policy is not updated",
+			annotations: map[string]string{
+				cleanupPolicyAnnotation: "tenant",
+			},
+			claimNamespace:     "tenant",
+			expectedUpdated:    false,
+			expectedPolicy:     "tenant",
+			expectedFinalizers: []string{"kubernetes.io/pv-protection", testFinalizer},
+			expectedHandled:    true,
+		},
+		{
+			description: "labeled volume no longer selected drops cleanup policy and stays handled",
+			labels: map[string]string{
+				policy.VolumeLabel: policy.VolumeLabelValue,
+			},
+			annotations: map[string]string{
+				cleanupPolicyAnnotation: "tenant",
+			},
+			claimNamespace:     "other",
+			expectedUpdated:    true,
+			expectedPolicy:     "",
+			expectedFinalizers: []string{"kubernetes.io/pv-protection", testFinalizer},
+			expectedHandled:    true,
+		},
+		{
+			description: "unlabeled volume no longer selected drops cleanup policy and finalizer",
+			annotations: map[string]string{
+				cleanupPolicyAnnotation: "tenant",
+			},
+			claimNamespace:     "other",
+			expectedUpdated:    true,
+			expectedPolicy:     "",
+			expectedFinalizers: []string{"kubernetes.io/pv-protection"},
+			expectedHandled:    false,
+		},
+		{
+			description: "volume being cleaned keeps cleanup policy no longer selecting it",
+			annotations: map[string]string{
+				cleanupPolicyAnnotation: "tenant",
+				recycleStateAnnotation:  cleaning,
+			},
+			claimNamespace:     "other",
+			expectedUpdated:    false,
+			expectedPolicy:     "tenant",
+			expectedFinalizers: []string{"kubernetes.io/pv-protection", testFinalizer},
+			expectedHandled:    true,
+		},
+		{
+			description:        "volume not selected by cleanup policy is not handled",
+			claimNamespace:     "other",
+			expectedUpdated:    false,
+			expectedPolicy:     "",
+			expectedFinalizers: []string{"kubernetes.io/pv-protection", testFinalizer},
+			expectedHandled:    false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := newPolicyVolume(tc.claimNamespace)
+			pv.Labels = tc.labels
+			pv.Annotations = tc.annotations
+			k8sClient := fake.NewSimpleClientset(pv)
+			p := newTenantPolicy()
+			newResource := newPolicyResource(t, k8sClient, p)
+
+			updated, err := newResource.ensurePolicy(context.TODO(), pv)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned ensuring policy: %s\n", i+1, err)
+			}
+			if updated != tc.expectedUpdated {
+				t.Fatalf("case %d expected updated %t got %t", i+1, tc.expectedUpdated, updated)
+			}
+
+			result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+			if result.Annotations[cleanupPolicyAnnotation] != tc.expectedPolicy {
+				t.Fatalf("case %d expected cleanup policy %#q got %#q", i+1, tc.expectedPolicy, result.Annotations[cleanupPolicyAnnotation])
+			}
+			if result.Labels[policy.VolumeLabel] != tc.labels[policy.VolumeLabel] {
+				t.Fatalf("case %d expected cleanup label %#q got %#q", i+1, tc.labels[policy.VolumeLabel], result.Labels[policy.VolumeLabel])
+			}
+			if !reflect.DeepEqual(result.Finalizers, tc.expectedFinalizers) {
+				t.Fatalf("case %d expected finalizers %v got %v", i+1, tc.expectedFinalizers, result.Finalizers)
+			}
+			if Handles(p, result) != tc.expectedHandled {
+				t.Fatalf("case %d expected handled %t got %t", i+1, tc.expectedHandled, !tc.expectedHandled)
+			}
+		})
+	}
+}
+
+func Test_Resource_ensurePolicy_ReleasedAfterPolicyRemoval(t *testing.T) {
+	pv := newPolicyVolume("tenant")
+	k8sClient := fake.NewSimpleClientset(pv)
+	p := newTenantPolicy()
+	newResource := newPolicyResource(t, k8sClient, p)
+
+	_, err := newResource.ensurePolicy(context.TODO(), pv)
+	if err != nil {
+		t.Fatalf("unexpected error returned ensuring policy: %s\n", err)
+	}
+	pv, err = k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error returned getting volume: %s\n", err)
+	}
+	if !Handles(p, pv) {
+		t.Fatalf("expected volume selected by cleanup policy to be handled")
+	}
+
+	p.SetRules(nil)
+
+	_, err = newResource.ensurePolicy(context.TODO(), pv)
+	if err != nil {
+		t.Fatalf("unexpected error returned ensuring policy: %s\n", err)
+	}
+	pv, err = k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error returned getting volume: %s\n", err)
+	}
+
+	pv.Spec.ClaimRef.UID = "released"
+	pv.Status.Phase = apiv1.VolumeReleased
+	pv, err = k8sClient.CoreV1().PersistentVolumes().Update(pv)
+	if err != nil {
+		t.Fatalf("unexpected error returned releasing volume: %s\n", err)
+	}
+
+	if Handles(p, pv) {
+		t.Fatalf("expected volume released after cleanup policy removal not to be handled")
+	}
+	if len(pv.Labels) != 0 || len(pv.Annotations) != 0 {
+		t.Fatalf("expected no labels and annotations got %v and %v", pv.Labels, pv.Annotations)
+	}
+	if !reflect.DeepEqual(pv.Finalizers, []string{"kubernetes.io/pv-protection"}) {
+		t.Fatalf("expected finalizers %v got %v", []string{"kubernetes.io/pv-protection"}, pv.Finalizers)
+	}
+}
+
+func newPolicyResource(t *testing.T, k8sClient *fake.Clientset, p *policy.Policy) *Resource {
+	resourceConfig := Config{
+		Cleaners:      cleaner.Builtin(),
+		CtrlClient:    newCtrlClient(),
+		EventRecorder: &record.FakeRecorder{},
+		Finalizer:     testFinalizer,
+		K8sClient:     k8sClient,
+		Logger:        microloggertest.New(),
+		Namespace:     metav1.NamespaceSystem,
+		Policy:        p,
+	}
+	newResource, err := New(resourceConfig)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	return newResource
+}
+
+func newPolicyVolume(claimNamespace string) *apiv1.PersistentVolume {
+	return &apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "TestPersistentVolume",
+			Finalizers: []string{
+				"kubernetes.io/pv-protection",
+				testFinalizer,
+			},
+		},
+		Spec: apiv1.PersistentVolumeSpec{
+			ClaimRef: &apiv1.ObjectReference{
+				Name:      "data",
+				Namespace: claimNamespace,
+			},
+		},
+		Status: apiv1.PersistentVolumeStatus{
+			Phase: apiv1.VolumeBound,
+		},
+	}
+}
+
+func newTenantPolicy() *policy.Policy {
+	p := &policy.Policy{}
+	p.SetRules([]policy.Rule{
+		{
+			Name: "tenant",
+			Selector: policy.Selector{
+				ClaimNamespaces: []string{"tenant"},
+			},
+		},
+	})
+
+	return p
+}
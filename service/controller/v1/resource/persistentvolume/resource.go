@@ -2,53 +2,155 @@ package persistentvolume
 
 import (
 	"fmt"
+	"strconv"
 
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
-	batchv1 "k8s.io/api/batch/v1"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
 )
 
 const (
-	defaultStorageClass    = "default"
-	name                   = "persistentvolume"
-	storageClassAnnotation = "volume.beta.kubernetes.io/storage-class"
-	recycleStateAnnotation = "pv-cleaner-operator.giantswarm.io/volume-recycle-state"
+	defaultBlockStrategy       = cleaner.WipeHeaders
+	defaultRetries             = 3
+	defaultStrategy            = cleaner.DeleteFiles
+	cleanupAfterAnnotation     = recycle.CleanupAfterAnnotation
+	cleanupPolicyAnnotation    = "pv-cleaner-operator.giantswarm.io/cleanup-policy"
+	failureMessageAnnotation   = recycle.FailureMessageAnnotation
+	failureReasonAnnotation    = recycle.FailureReasonAnnotation
+	gracePeriodAnnotation      = "pv-cleaner-operator.giantswarm.io/grace-period"
+	holdAnnotation             = recycle.HoldAnnotation
+	methodAnnotation           = cleaner.MethodAnnotation
+	name                       = "persistentvolume"
+	parametersAnnotation       = "pv-cleaner-operator.giantswarm.io/cleanup-parameters"
+	podAnnotation              = "pv-cleaner-operator.giantswarm.io/cleanup-pod"
+	previousClaimAnnotation    = recycle.PreviousClaimAnnotation
+	releasedAtAnnotation       = "pv-cleaner-operator.giantswarm.io/released-at"
+	restoreAnnotation          = recycle.RestoreAnnotation
+	retriedAtAnnotation        = "pv-cleaner-operator.giantswarm.io/retried-at"
+	imageDigestAnnotation      = cleaner.ImageDigestAnnotation
+	retriesAnnotation          = "pv-cleaner-operator.giantswarm.io/cleanup-retries"
+	stateChangedAtAnnotation   = recycle.StateChangedAtAnnotation
+	recycleStartedAtAnnotation = "pv-cleaner-operator.giantswarm.io/recycle-started-at"
+	recycleStateAnnotation     = recycle.StateAnnotation
+	strategyAnnotation         = "pv-cleaner-operator.giantswarm.io/cleanup-strategy"
 )
 
 const (
-	cleaning string = "Cleaning"
-	teardown string = "Teardown"
-	recycled string = "Recycled"
+	cleaning = string(recycle.Cleaning)
+	teardown = string(recycle.Teardown)
+	recycled = string(recycle.Recycled)
 )
 
 // Config describes resource configuration.
 type Config struct {
+	// Cleaners are the scrub strategies volumes can select using the
+	// cleanup strategy annotation.
+	Cleaners []cleaner.Interface
+	// Container configures the container of cleanup jobs. The image of the
+	// policy of a volume takes precedence over the image configured here.
+	Container cleaner.ContainerConfig
+	// CtrlClient is used to manage the volume scrubs recording every cleanup
+	// attempt.
+	CtrlClient client.Client
+	// EventRecorder emits events on volumes for every step of their
+	// recycling.
+	EventRecorder record.EventRecorder
+	// Finalizer is the finalizer the controller adds to the volumes it
+	// handles. It is removed from volumes which are no longer handled.
+	Finalizer string
 	K8sClient kubernetes.Interface
 	Logger    micrologger.Logger
+	// Namespace is the namespace cleanup claims, cleanup jobs and cleanup pods
+	// are created in.
+	Namespace string
+	// Policy maps storage classes and cleanup policies to the cleanup
+	// settings of their volumes.
+	Policy *policy.Policy
 }
 
 // Resource stores resource configuration.
 type Resource struct {
-	k8sClient kubernetes.Interface
-	logger    micrologger.Logger
+	cleaners      map[string]cleaner.Interface
+	container     cleaner.ContainerConfig
+	ctrlClient    client.Client
+	eventRecorder record.EventRecorder
+	finalizer     string
+	k8sClient     kubernetes.Interface
+	logger        micrologger.Logger
+	namespace     string
+	policy        *policy.Policy
 }
 
 // New is factory for resource objects.
 func New(config Config) (*Resource, error) {
+	if len(config.Cleaners) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "config.Cleaners must not be empty")
+	}
+	if len(config.Container.Args) > 0 && len(config.Container.Command) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "config.Container.Args must be empty without config.Container.Command")
+	}
+	switch config.Container.ImagePullPolicy {
+	case "", apiv1.PullAlways, apiv1.PullIfNotPresent, apiv1.PullNever:
+	default:
+		return nil, microerror.Maskf(invalidConfigError, "config.Container.ImagePullPolicy must be one of %#q, %#q or %#q, got %#q", apiv1.PullAlways, apiv1.PullIfNotPresent, apiv1.PullNever, config.Container.ImagePullPolicy)
+	}
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.CtrlClient must not be empty")
+	}
+	if config.EventRecorder == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.EventRecorder must not be empty")
+	}
 	if config.K8sClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
 	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
 	}
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.Namespace must not be empty")
+	}
+	if config.Policy == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Policy must not be empty")
+	}
+
+	cleaners := map[string]cleaner.Interface{}
+	for _, c := range config.Cleaners {
+		cleaners[c.Name()] = c
+	}
+	for _, s := range []string{defaultStrategy, defaultBlockStrategy} {
+		if _, ok := cleaners[s]; !ok {
+			return nil, microerror.Maskf(invalidConfigError, "config.Cleaners must contain strategy %#q", s)
+		}
+	}
+	for _, c := range config.Policy.Classes() {
+		for _, s := range []string{c.Strategy, c.BlockStrategy} {
+			if _, ok := cleaners[s]; s != "" && !ok {
+				return nil, microerror.Maskf(invalidConfigError, "config.Policy selects unknown strategy %#q", s)
+			}
+		}
+	}
 
 	resource := &Resource{
-		k8sClient: config.K8sClient,
-		logger:    config.Logger,
+		cleaners:      cleaners,
+		container:     config.Container,
+		ctrlClient:    config.CtrlClient,
+		eventRecorder: config.EventRecorder,
+		finalizer:     config.Finalizer,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+		namespace:     config.Namespace,
+		policy:        config.Policy,
 	}
 	return resource, nil
 }
@@ -113,53 +215,61 @@ func pvToRecyclePV(v interface{}) (*RecyclePersistentVolume, error) {
 		Name:         pv.Name,
 		State:        pv.Status.Phase,
 		RecycleState: getVolumeAnnotation(pv, recycleStateAnnotation),
+		Command:      pv.Annotations[recycle.CommandAnnotation],
 	}
 
 	return rpv, nil
 }
 
 // newRecycleStateAnnotation create new PersistentVolume object with
-// updated recycle state annotation.
+// updated recycle state annotation. The new object is a full copy of the
+// given one, except for the claim reference, which is cleared so that the
+// volume can be bound again. The resource version is kept, so that updating
+// the volume fails with a conflict instead of overwriting concurrent changes.
+// The slot label is updated to match the new recycle state.
 func (r *Resource) newRecycleStateAnnotation(pv *apiv1.PersistentVolume, recycleAnnotation string) (*apiv1.PersistentVolume, error) {
+	updatedpv := pv.DeepCopy()
+	updatedpv.Spec.ClaimRef = nil
 
-	updatedpv := &apiv1.PersistentVolume{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        pv.Name,
-			Annotations: pv.Annotations,
-			Labels:      pv.Labels,
-		},
-		Spec: apiv1.PersistentVolumeSpec{
-			Capacity:                      pv.Spec.Capacity,
-			AccessModes:                   pv.Spec.AccessModes,
-			StorageClassName:              pv.Spec.StorageClassName,
-			PersistentVolumeReclaimPolicy: pv.Spec.PersistentVolumeReclaimPolicy,
-			PersistentVolumeSource:        pv.Spec.PersistentVolumeSource,
-		},
+	if updatedpv.Annotations == nil {
+		updatedpv.Annotations = map[string]string{}
 	}
 
 	r.logger.Log("persistentvolume", pv.Name, "set new recycle annotation", recycleAnnotation)
-	updatedpv.ObjectMeta.Annotations[recycleStateAnnotation] = recycleAnnotation
+	updatedpv.Annotations[recycleStateAnnotation] = recycleAnnotation
+	setSlotLabel(updatedpv)
 
 	return updatedpv, nil
 }
 
-// newPvc returns k8s PersistentVolumeClaim object,
+// claimName returns the name of the cleanup claim of the given persistent
+// volume.
+func claimName(pv *apiv1.PersistentVolume) string {
+	return fmt.Sprintf("pv-cleaner-claim-%s", pv.Name)
+}
+
+// jobName returns the name of the cleanup job of the given persistent volume.
+func jobName(pv *apiv1.PersistentVolume) string {
+	return fmt.Sprintf("pv-cleaner-job-%s", claimName(pv))
+}
+
+// newPvc returns k8s PersistentVolumeClaim object in the given namespace,
 // which bounds persistent volume from function parameter.
-func newPvc(pv *apiv1.PersistentVolume) *apiv1.PersistentVolumeClaim {
+func newPvc(pv *apiv1.PersistentVolume, namespace string) *apiv1.PersistentVolumeClaim {
+	storageClassAnnotationValue := policy.StorageClass(pv)
 
-	storageClassAnnotationValue, ok := pv.Annotations[storageClassAnnotation]
-	if !ok {
-		if pv.Spec.StorageClassName != "" {
-			storageClassAnnotationValue = pv.Spec.StorageClassName
-		} else {
-			storageClassAnnotationValue = defaultStorageClass
-		}
-	}
+	volumeModeValue := volumeMode(pv)
 
 	pvc := &apiv1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("pv-cleaner-claim-%s", pv.Name),
-			Namespace: metav1.NamespaceSystem,
+			Name:      claimName(pv),
+			Namespace: namespace,
+			Annotations: map[string]string{
+				cleaner.VolumeAnnotation: pv.Name,
+			},
+			Labels: map[string]string{
+				cleaner.CleanupLabel: cleaner.CleanupLabelValue,
+			},
 		},
 		Spec: apiv1.PersistentVolumeClaimSpec{
 			AccessModes:      pv.Spec.AccessModes,
@@ -167,6 +277,7 @@ func newPvc(pv *apiv1.PersistentVolume) *apiv1.PersistentVolumeClaim {
 			Resources: apiv1.ResourceRequirements{
 				Requests: pv.Spec.Capacity,
 			},
+			VolumeMode: &volumeModeValue,
 			VolumeName: pv.Name,
 		},
 	}
@@ -174,52 +285,150 @@ func newPvc(pv *apiv1.PersistentVolume) *apiv1.PersistentVolumeClaim {
 	return pvc
 }
 
-// newCleanupJob returns k8s job objects,
-// which runs busybox container, mounts claim from the function parameter
-// and run shell command to cleanup mount path.
-func newCleanupJob(pvc *apiv1.PersistentVolumeClaim) *batchv1.Job {
-	job := &batchv1.Job{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: fmt.Sprintf("pv-cleaner-job-%s", pvc.Name),
-		},
-		Spec: batchv1.JobSpec{
-			Template: apiv1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "pv-cleaner-pod",
-				},
-				Spec: apiv1.PodSpec{
-					Containers: []apiv1.Container{
-						apiv1.Container{
-							Name:  fmt.Sprintf("pv-cleaner-container-%s", pvc.Name),
-							Image: "busybox",
-							Command: []string{
-								"/bin/sh",
-								"-c",
-								"test -e /scrub && rm -rf /scrub/..?* /scrub/.[!.]* /scrub/*  && test -z \"$(ls -A /scrub)\" || exit 1",
-							},
-							VolumeMounts: []apiv1.VolumeMount{
-								apiv1.VolumeMount{
-									Name:      "pv-cleaner-mount",
-									MountPath: "/scrub",
-								},
-							},
-						},
-					},
-					RestartPolicy: "Never",
-					Volumes: []apiv1.Volume{
-						apiv1.Volume{
-							Name: "pv-cleaner-mount",
-							VolumeSource: apiv1.VolumeSource{
-								PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{
-									ClaimName: pvc.Name,
-								},
-							},
-						},
-					},
-				},
-			},
-		},
+// volumeMode returns the volume mode of the given persistent volume. Volumes
+// without an explicit volume mode are filesystem volumes.
+func volumeMode(pv *apiv1.PersistentVolume) apiv1.PersistentVolumeMode {
+	if pv.Spec.VolumeMode == nil {
+		return apiv1.PersistentVolumeFilesystem
+	}
+
+	return *pv.Spec.VolumeMode
+}
+
+// volumePolicy returns the cleanup settings of the given persistent volume
+// based on the cleanup policy recorded on it, or on its storage class if no
+// cleanup policy selects it.
+func (r *Resource) volumePolicy(pv *apiv1.PersistentVolume) policy.Class {
+	_, c := r.volumePolicyScope(pv)
+	return c
+}
+
+// volumePolicyScope returns the cleanup settings of the given persistent
+// volume together with the scope they were looked up in, which is either the
+// cleanup policy recorded on the volume or its storage class. Volumes in the
+// same scope share its maxConcurrent limit.
+func (r *Resource) volumePolicyScope(pv *apiv1.PersistentVolume) (string, policy.Class) {
+	if name := pv.Annotations[cleanupPolicyAnnotation]; name != "" {
+		c, ok := r.policy.ForRule(name)
+		if ok {
+			return "cleanuppolicy/" + name, c
+		}
+	}
+
+	storageClass := policy.StorageClass(pv)
+	return "storageclass/" + storageClass, r.policy.ForStorageClass(storageClass)
+}
+
+// maxRetries returns the number of times a failed cleanup job of the given
+// persistent volume is retried.
+func (r *Resource) maxRetries(pv *apiv1.PersistentVolume) int {
+	retries := r.volumePolicy(pv).Retries
+	if retries == nil {
+		return defaultRetries
+	}
+
+	return *retries
+}
+
+// volumeRetries returns the number of times the cleanup job of the given
+// persistent volume was retried so far.
+func volumeRetries(pv *apiv1.PersistentVolume) int {
+	retries, err := strconv.Atoi(getVolumeAnnotation(pv, retriesAnnotation))
+	if err != nil {
+		return 0
+	}
+
+	return retries
+}
+
+// volumeParameters returns the scrub strategy parameters of the given
+// persistent volume. Parameters set in the cleanup parameters annotation, in
+// the form key1=value1,key2=value2, take precedence over the parameters of the
+// storage class policy.
+func (r *Resource) volumeParameters(pv *apiv1.PersistentVolume) (map[string]string, error) {
+	parameters := map[string]string{}
+	for k, v := range r.volumePolicy(pv).Parameters {
+		parameters[k] = v
+	}
+
+	annotationValue := getVolumeAnnotation(pv, parametersAnnotation)
+	if annotationValue != "" {
+		annotationParameters, err := labels.ConvertSelectorToLabelsMap(annotationValue)
+		if err != nil {
+			return nil, microerror.Maskf(invalidParametersError, "persistent volume %#q: %s", pv.Name, err)
+		}
+
+		for k, v := range annotationParameters {
+			parameters[k] = v
+		}
+	}
+
+	return parameters, nil
+}
+
+// podOverride is the content of the cleanup pod annotation of a volume.
+//
+//	resources:
+//	  limits:
+//	    memory: 64Mi
+//	tolerations:
+//	- key: node.giantswarm.io/storage
+//	  operator: Exists
+type podOverride struct {
+	cleaner.PodConfig
+	Resources *apiv1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// volumePod returns the cleanup pod settings and the container resources of
+// the given persistent volume. Settings of the cleanup pod annotation take
+// precedence over the policy of the volume.
+func (r *Resource) volumePod(pv *apiv1.PersistentVolume) (cleaner.PodConfig, apiv1.ResourceRequirements, error) {
+	class := r.volumePolicy(pv)
+
+	annotationValue := getVolumeAnnotation(pv, podAnnotation)
+	if annotationValue == "" {
+		return class.Pod, class.Resources, nil
+	}
+
+	var o podOverride
+	err := yaml.UnmarshalStrict([]byte(annotationValue), &o)
+	if err != nil {
+		return cleaner.PodConfig{}, apiv1.ResourceRequirements{}, microerror.Maskf(invalidPodError, "persistent volume %#q: %s", pv.Name, err)
+	}
+
+	resources := class.Resources
+	if o.Resources != nil {
+		resources = *o.Resources
+	}
+
+	return o.PodConfig.Merge(class.Pod), resources, nil
+}
+
+// volumeCleaner returns the scrub strategy selected by the cleanup strategy
+// annotation of the given persistent volume. Volumes without the annotation
+// are cleaned using the strategy their storage class policy defines for their
+// volume mode, or the default strategy for their volume mode if the policy
+// does not define one.
+func (r *Resource) volumeCleaner(pv *apiv1.PersistentVolume) (cleaner.Interface, error) {
+	strategy := getVolumeAnnotation(pv, strategyAnnotation)
+	if strategy == "" {
+		if volumeMode(pv) == apiv1.PersistentVolumeBlock {
+			strategy = r.volumePolicy(pv).BlockStrategy
+			if strategy == "" {
+				strategy = defaultBlockStrategy
+			}
+		} else {
+			strategy = r.volumePolicy(pv).Strategy
+			if strategy == "" {
+				strategy = defaultStrategy
+			}
+		}
+	}
+
+	c, ok := r.cleaners[strategy]
+	if !ok {
+		return nil, microerror.Maskf(unknownStrategyError, "persistent volume %#q selects unknown strategy %#q", pv.Name, strategy)
 	}
 
-	return job
+	return c, nil
 }
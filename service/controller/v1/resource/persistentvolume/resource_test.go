@@ -0,0 +1,345 @@
+package persistentvolume
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+)
+
+func Test_Resource_newRecycleStateAnnotation(t *testing.T) {
+	blockMode := apiv1.PersistentVolumeBlock
+	controller := true
+
+	testCases := []struct {
+		description              string
+		pv                       *apiv1.PersistentVolume
+		recycleAnnotation        string
+		expectedPersistentVolume *apiv1.PersistentVolume
+	}{
+		{
+			description: "local volume keeps node affinity, mount options, volume mode and metadata",
+			pv: &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "TestPersistentVolume",
+					ResourceVersion: "42",
+					UID:             "9b1b7fb4-4a36-4c5b-a1b0-d1a3f6fbd2c7",
+					Annotations: map[string]string{
+						"pv.kubernetes.io/provisioned-by":                        "local-volume-provisioner",
+						"pv-cleaner-operator.giantswarm.io/volume-recycle-state": recycled,
+					},
+					Labels: map[string]string{
+						"persistentvolume.giantswarm.io/cleanup-on-release": "true",
+					},
+					Finalizers: []string{
+						"kubernetes.io/pv-protection",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "apps/v1",
+							Kind:       "DaemonSet",
+							Name:       "local-volume-provisioner",
+							UID:        "0c2b5e0c-7c3e-4bd4-9b38-52d1b3d2d4a1",
+							Controller: &controller,
+						},
+					},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					AccessModes: []apiv1.PersistentVolumeAccessMode{
+						apiv1.ReadWriteOnce,
+					},
+					Capacity: apiv1.ResourceList{
+						apiv1.ResourceStorage: resource.MustParse("10Gi"),
+					},
+					ClaimRef: &apiv1.ObjectReference{
+						Kind:      "PersistentVolumeClaim",
+						Namespace: "default",
+						Name:      "data-0",
+						UID:       "5d0b9f4e-8a57-4d6f-8d2b-0e7b0e6f3f55",
+					},
+					MountOptions: []string{
+						"noatime",
+					},
+					NodeAffinity: &apiv1.VolumeNodeAffinity{
+						Required: &apiv1.NodeSelector{
+							NodeSelectorTerms: []apiv1.NodeSelectorTerm{
+								{
+									MatchExpressions: []apiv1.NodeSelectorRequirement{
+										{
+											Key:      "kubernetes.io/hostname",
+											Operator: apiv1.NodeSelectorOpIn,
+											Values:   []string{"worker-1"},
+										},
+									},
+								},
+							},
+						},
+					},
+					PersistentVolumeReclaimPolicy: apiv1.PersistentVolumeReclaimRetain,
+					PersistentVolumeSource: apiv1.PersistentVolumeSource{
+						Local: &apiv1.LocalVolumeSource{
+							Path: "/mnt/disks/ssd1",
+						},
+					},
+					StorageClassName: "local-storage",
+					VolumeMode:       &blockMode,
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: apiv1.VolumeReleased,
+				},
+			},
+			recycleAnnotation: cleaning,
+			expectedPersistentVolume: &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "TestPersistentVolume",
+					ResourceVersion: "42",
+					UID:             "9b1b7fb4-4a36-4c5b-a1b0-d1a3f6fbd2c7",
+					Annotations: map[string]string{
+						"pv.kubernetes.io/provisioned-by":                        "local-volume-provisioner",
+						"pv-cleaner-operator.giantswarm.io/volume-recycle-state": cleaning,
+					},
+					Labels: map[string]string{
+						"persistentvolume.giantswarm.io/cleanup-on-release": "true",
+						"pv-cleaner-operator.giantswarm.io/cleanup-slot":    "true",
+					},
+					Finalizers: []string{
+						"kubernetes.io/pv-protection",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "apps/v1",
+							Kind:       "DaemonSet",
+							Name:       "local-volume-provisioner",
+							UID:        "0c2b5e0c-7c3e-4bd4-9b38-52d1b3d2d4a1",
+							Controller: &controller,
+						},
+					},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					AccessModes: []apiv1.PersistentVolumeAccessMode{
+						apiv1.ReadWriteOnce,
+					},
+					Capacity: apiv1.ResourceList{
+						apiv1.ResourceStorage: resource.MustParse("10Gi"),
+					},
+					MountOptions: []string{
+						"noatime",
+					},
+					NodeAffinity: &apiv1.VolumeNodeAffinity{
+						Required: &apiv1.NodeSelector{
+							NodeSelectorTerms: []apiv1.NodeSelectorTerm{
+								{
+									MatchExpressions: []apiv1.NodeSelectorRequirement{
+										{
+											Key:      "kubernetes.io/hostname",
+											Operator: apiv1.NodeSelectorOpIn,
+											Values:   []string{"worker-1"},
+										},
+									},
+								},
+							},
+						},
+					},
+					PersistentVolumeReclaimPolicy: apiv1.PersistentVolumeReclaimRetain,
+					PersistentVolumeSource: apiv1.PersistentVolumeSource{
+						Local: &apiv1.LocalVolumeSource{
+							Path: "/mnt/disks/ssd1",
+						},
+					},
+					StorageClassName: "local-storage",
+					VolumeMode:       &blockMode,
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: apiv1.VolumeReleased,
+				},
+			},
+		},
+		{
+			description: "volume without annotations gets recycle annotation",
+			pv: &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "TestPersistentVolume",
+					ResourceVersion: "7",
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					PersistentVolumeSource: apiv1.PersistentVolumeSource{
+						HostPath: &apiv1.HostPathVolumeSource{
+							Path: "/var/lib/data",
+						},
+					},
+				},
+			},
+			recycleAnnotation: recycled,
+			expectedPersistentVolume: &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "TestPersistentVolume",
+					ResourceVersion: "7",
+					Annotations: map[string]string{
+						"pv-cleaner-operator.giantswarm.io/volume-recycle-state": recycled,
+					},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					PersistentVolumeSource: apiv1.PersistentVolumeSource{
+						HostPath: &apiv1.HostPathVolumeSource{
+							Path: "/var/lib/data",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		resourceConfig := Config{
+			Cleaners:      cleaner.Builtin(),
+			CtrlClient:    newCtrlClient(),
+			EventRecorder: &record.FakeRecorder{},
+			K8sClient:     fake.NewSimpleClientset(),
+			Logger:        microloggertest.New(),
+			Namespace:     metav1.NamespaceSystem,
+			Policy:        &policy.Policy{},
+		}
+		newResource, err = New(resourceConfig)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			original := tc.pv.DeepCopy()
+
+			result, err := newResource.newRecycleStateAnnotation(tc.pv, tc.recycleAnnotation)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned creating recycle state annotation: %s\n", i+1, err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedPersistentVolume, result) {
+				t.Fatalf("case %d expected %#v got %#v", i+1, tc.expectedPersistentVolume, result)
+			}
+			if !reflect.DeepEqual(original, tc.pv) {
+				t.Fatalf("case %d expected given volume to be unchanged, got %#v", i+1, tc.pv)
+			}
+		})
+	}
+}
+
+func Test_Resource_volumePod(t *testing.T) {
+	user := int64(1000)
+	policyPod := cleaner.PodConfig{
+		NodeSelector: map[string]string{
+			"node.giantswarm.io/storage": "true",
+		},
+		PriorityClassName: "system-node-critical",
+		RunAsUser:         &user,
+	}
+	policyResources := apiv1.ResourceRequirements{
+		Requests: apiv1.ResourceList{
+			apiv1.ResourceCPU: resource.MustParse("100m"),
+		},
+	}
+
+	testCases := []struct {
+		description       string
+		annotation        string
+		expectedPod       cleaner.PodConfig
+		expectedResources apiv1.ResourceRequirements
+		errorMatcher      func(error) bool
+	}{
+		{
+			description:       "volume without annotation uses policy",
+			annotation:        "",
+			expectedPod:       policyPod,
+			expectedResources: policyResources,
+		},
+		{
+			description: "annotation overrides policy settings it sets",
+			annotation:  "tolerations:\n- key: node.giantswarm.io/storage\n  operator: Exists\npriorityClassName: cleanup\nresources:\n  limits:\n    memory: 64Mi\n",
+			expectedPod: cleaner.PodConfig{
+				NodeSelector:      policyPod.NodeSelector,
+				PriorityClassName: "cleanup",
+				RunAsUser:         &user,
+				Tolerations: []apiv1.Toleration{
+					{
+						Key:      "node.giantswarm.io/storage",
+						Operator: apiv1.TolerationOpExists,
+					},
+				},
+			},
+			expectedResources: apiv1.ResourceRequirements{
+				Limits: apiv1.ResourceList{
+					apiv1.ResourceMemory: resource.MustParse("64Mi"),
+				},
+			},
+		},
+		{
+			description:  "annotation with unknown setting is rejected",
+			annotation:   "nodeName: worker-1\n",
+			errorMatcher: IsInvalidPod,
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		resourceConfig := Config{
+			Cleaners:      cleaner.Builtin(),
+			CtrlClient:    newCtrlClient(),
+			EventRecorder: &record.FakeRecorder{},
+			K8sClient:     fake.NewSimpleClientset(),
+			Logger:        microloggertest.New(),
+			Namespace:     metav1.NamespaceSystem,
+			Policy: &policy.Policy{
+				Default: policy.Class{
+					Pod:       policyPod,
+					Resources: policyResources,
+				},
+			},
+		}
+		newResource, err = New(resourceConfig)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume",
+					Annotations: map[string]string{
+						podAnnotation: tc.annotation,
+					},
+				},
+			}
+
+			pod, resources, err := newResource.volumePod(pv)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("case %d expected error matcher to match, got %#v", i+1, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting pod settings: %s\n", i+1, err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedPod, pod) {
+				t.Fatalf("case %d expected pod settings %#v got %#v", i+1, tc.expectedPod, pod)
+			}
+			if !reflect.DeepEqual(tc.expectedResources, resources) {
+				t.Fatalf("case %d expected resources %#v got %#v", i+1, tc.expectedResources, resources)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package persistentvolume
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+)
+
+// restoreClaim binds the released volume to the claim recreated with the
+// namespace and name of the claim the volume was bound to, as recorded in the
+// previous claim annotation, instead of cleaning it. The volume must be within
+// its grace period or held, and the pending claim must opt in using the
+// restore annotation and fit the volume. Volumes are only restored before
+// they are queued for cleaning, since the claim reference is cleared once
+// they are cleaned. The claim reference of the volume is pointed to the new
+// claim, so that Kubernetes binds them. It returns true if the volume was
+// restored.
+func (r *Resource) restoreClaim(ctx context.Context, pv *apiv1.PersistentVolume) (bool, error) {
+	namespace, name, ok := splitNamespacedName(getVolumeAnnotation(pv, previousClaimAnnotation))
+	if !ok {
+		return false, nil
+	}
+
+	// Volumes whose release was not recorded yet, or whose grace period
+	// passed, are not restored.
+	releasedAt, err := time.Parse(time.RFC3339, getVolumeAnnotation(pv, releasedAtAnnotation))
+	if err != nil {
+		return false, nil
+	}
+	gracePeriod, err := r.volumeGracePeriod(pv)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	if !isHeld(pv) && !time.Now().Before(releasedAt.Add(gracePeriod)) {
+		return false, nil
+	}
+
+	pvc, err := r.k8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	// The claim the volume was released by still exists, e.g. because it is
+	// being deleted.
+	if ref := pv.Spec.ClaimRef; ref != nil && pvc.UID == ref.UID {
+		return false, nil
+	}
+	if pvc.Annotations[restoreAnnotation] != "true" {
+		return false, nil
+	}
+	if pvc.Status.Phase != apiv1.ClaimPending || pvc.DeletionTimestamp != nil {
+		return false, nil
+	}
+	if pvc.Spec.VolumeName != "" && pvc.Spec.VolumeName != pv.Name {
+		return false, nil
+	}
+
+	reason := claimMismatch(pv, pvc)
+	if reason != "" {
+		r.logger.LogCtx(ctx, "level", "warning", "persistentvolume", pv.Name, "message", fmt.Sprintf("not restoring claim %s/%s: %s", pvc.Namespace, pvc.Name, reason))
+		return false, nil
+	}
+
+	updatedpv := pv.DeepCopy()
+	updatedpv.Spec.ClaimRef = &apiv1.ObjectReference{
+		APIVersion:      "v1",
+		Kind:            "PersistentVolumeClaim",
+		Name:            pvc.Name,
+		Namespace:       pvc.Namespace,
+		ResourceVersion: pvc.ResourceVersion,
+		UID:             pvc.UID,
+	}
+	delete(updatedpv.Annotations, releasedAtAnnotation)
+	delete(updatedpv.Annotations, cleanupAfterAnnotation)
+	delete(updatedpv.Annotations, previousClaimAnnotation)
+
+	_, err = r.k8sClient.CoreV1().PersistentVolumes().Update(updatedpv)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "info", "persistentvolume", pv.Name, "message", fmt.Sprintf("restored claim %s/%s", pvc.Namespace, pvc.Name))
+	r.eventRecorder.Eventf(pv, apiv1.EventTypeNormal, claimRestoredReason, "restored volume to recreated claim %s/%s instead of cleaning it", pvc.Namespace, pvc.Name)
+	r.eventRecorder.Eventf(pvc, apiv1.EventTypeNormal, claimRestoredReason, "restored released volume %#q", pv.Name)
+
+	return true, nil
+}
+
+// claimMismatch returns why the given claim can not be bound to the given
+// volume, or an empty string if it can. Kubernetes does not bind claims which
+// do not fit the volume they are pre-bound to, which would leave the volume
+// reserved for the claim instead of being cleaned.
+func claimMismatch(pv *apiv1.PersistentVolume, pvc *apiv1.PersistentVolumeClaim) string {
+	if policy.ClaimStorageClass(pvc) != policy.StorageClass(pv) {
+		return fmt.Sprintf("claim requests storage class %#q, volume has %#q", policy.ClaimStorageClass(pvc), policy.StorageClass(pv))
+	}
+
+	mode := apiv1.PersistentVolumeFilesystem
+	if pvc.Spec.VolumeMode != nil {
+		mode = *pvc.Spec.VolumeMode
+	}
+	if mode != volumeMode(pv) {
+		return fmt.Sprintf("claim requests volume mode %#q, volume has %#q", mode, volumeMode(pv))
+	}
+
+	for _, m := range pvc.Spec.AccessModes {
+		if !hasAccessMode(pv, m) {
+			return fmt.Sprintf("claim requests access mode %#q, volume does not support it", m)
+		}
+	}
+
+	requested := pvc.Spec.Resources.Requests[apiv1.ResourceStorage]
+	capacity := pv.Spec.Capacity[apiv1.ResourceStorage]
+	if requested.Cmp(capacity) > 0 {
+		return fmt.Sprintf("claim requests %s, volume has %s", requested.String(), capacity.String())
+	}
+
+	return ""
+}
+
+// hasAccessMode checks whether the given volume supports the given access
+// mode.
+func hasAccessMode(pv *apiv1.PersistentVolume, mode apiv1.PersistentVolumeAccessMode) bool {
+	for _, m := range pv.Spec.AccessModes {
+		if m == mode {
+			return true
+		}
+	}
+
+	return false
+}
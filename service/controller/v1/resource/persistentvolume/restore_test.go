@@ -0,0 +1,199 @@
+package persistentvolume
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Resource_startCleaning_RestoreClaim(t *testing.T) {
+	testCases := []struct {
+		description          string
+		releasedAgo          time.Duration
+		held                 bool
+		unrecorded           bool
+		claimUID             types.UID
+		claimAnnotations     map[string]string
+		claimStorageClass    string
+		expectedClaimUID     types.UID
+		expectedRecycleState string
+	}{
+		{
+			description:          "recreated claim opting in is restored within grace period",
+			releasedAgo:          time.Minute,
+			claimUID:             "new",
+			claimAnnotations:     map[string]string{restoreAnnotation: "true"},
+			claimStorageClass:    "local-storage",
+			expectedClaimUID:     "new",
+			expectedRecycleState: "",
+		},
+		{
+			description:          "recreated claim not opting in is not restored",
+			releasedAgo:          time.Minute,
+			claimUID:             "new",
+			claimAnnotations:     map[string]string{},
+			claimStorageClass:    "local-storage",
+			expectedClaimUID:     "old",
+			expectedRecycleState: "",
+		},
+		{
+			description:          "recreated claim is not restored after grace period",
+			releasedAgo:          2 * time.Hour,
+			claimUID:             "new",
+			claimAnnotations:     map[string]string{restoreAnnotation: "true"},
+			claimStorageClass:    "local-storage",
+			expectedClaimUID:     "old",
+			expectedRecycleState: string(recycle.Queued),
+		},
+		{
+			description:          "recreated claim is restored to held volume after grace period",
+			releasedAgo:          2 * time.Hour,
+			held:                 true,
+			claimUID:             "new",
+			claimAnnotations:     map[string]string{restoreAnnotation: "true"},
+			claimStorageClass:    "local-storage",
+			expectedClaimUID:     "new",
+			expectedRecycleState: "",
+		},
+		{
+			description:          "recreated claim is not restored to volume without recorded previous claim",
+			releasedAgo:          time.Minute,
+			unrecorded:           true,
+			claimUID:             "new",
+			claimAnnotations:     map[string]string{restoreAnnotation: "true"},
+			claimStorageClass:    "local-storage",
+			expectedClaimUID:     "old",
+			expectedRecycleState: "",
+		},
+		{
+			description:          "recreated claim of other storage class is not restored",
+			releasedAgo:          time.Minute,
+			claimUID:             "new",
+			claimAnnotations:     map[string]string{restoreAnnotation: "true"},
+			claimStorageClass:    "standard",
+			expectedClaimUID:     "old",
+			expectedRecycleState: "",
+		},
+		{
+			description:          "claim the volume was released by is not restored",
+			releasedAgo:          time.Minute,
+			claimUID:             "old",
+			claimAnnotations:     map[string]string{restoreAnnotation: "true"},
+			claimStorageClass:    "local-storage",
+			expectedClaimUID:     "old",
+			expectedRecycleState: "",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			annotations := map[string]string{
+				releasedAtAnnotation: time.Now().UTC().Add(-tc.releasedAgo).Format(time.RFC3339),
+			}
+			if !tc.unrecorded {
+				annotations[previousClaimAnnotation] = "default/data"
+			}
+			if tc.held {
+				annotations[holdAnnotation] = "true"
+			}
+
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "TestPersistentVolume",
+					Annotations: annotations,
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					AccessModes: []apiv1.PersistentVolumeAccessMode{apiv1.ReadWriteOnce},
+					Capacity: apiv1.ResourceList{
+						apiv1.ResourceStorage: resource.MustParse("10Gi"),
+					},
+					ClaimRef: &apiv1.ObjectReference{
+						Namespace: "default",
+						Name:      "data",
+						UID:       "old",
+					},
+					StorageClassName: "local-storage",
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: apiv1.VolumeReleased,
+				},
+			}
+			pvc := &apiv1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "data",
+					Namespace:   "default",
+					Annotations: tc.claimAnnotations,
+					UID:         tc.claimUID,
+				},
+				Spec: apiv1.PersistentVolumeClaimSpec{
+					AccessModes: []apiv1.PersistentVolumeAccessMode{apiv1.ReadWriteOnce},
+					Resources: apiv1.ResourceRequirements{
+						Requests: apiv1.ResourceList{
+							apiv1.ResourceStorage: resource.MustParse("10Gi"),
+						},
+					},
+					StorageClassName: &tc.claimStorageClass,
+				},
+				Status: apiv1.PersistentVolumeClaimStatus{
+					Phase: apiv1.ClaimPending,
+				},
+			}
+			k8sClient := fake.NewSimpleClientset(pv, pvc)
+
+			var err error
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					Cleaners:      cleaner.Builtin(),
+					CtrlClient:    newCtrlClient(),
+					EventRecorder: &record.FakeRecorder{},
+					K8sClient:     k8sClient,
+					Logger:        microloggertest.New(),
+					Namespace:     metav1.NamespaceSystem,
+					Policy: &policy.Policy{
+						Default: policy.Class{
+							GracePeriod: &metav1.Duration{Duration: time.Hour},
+						},
+					},
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			transition, err := recycle.NextTransition(apiv1.VolumeReleased, recycle.Recycled)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting transition: %s\n", i+1, err)
+			}
+
+			err = newResource.startCleaning(context.TODO(), pv.DeepCopy(), transition)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned starting cleaning: %s\n", i+1, err)
+			}
+
+			result, err := k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+			if result.Spec.ClaimRef == nil || result.Spec.ClaimRef.UID != tc.expectedClaimUID {
+				t.Fatalf("case %d expected claim reference with uid %#q got %#v", i+1, tc.expectedClaimUID, result.Spec.ClaimRef)
+			}
+			if result.Annotations[recycleStateAnnotation] != tc.expectedRecycleState {
+				t.Fatalf("case %d expected recycle state %#q got %#q", i+1, tc.expectedRecycleState, result.Annotations[recycleStateAnnotation])
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package persistentvolume
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+)
+
+const (
+	// maxScrubNameLength is the maximum length of the name of a volume
+	// scrub, minus the suffix identifying the cleanup job.
+	maxScrubNameLength = 244
+)
+
+// scrubName returns the name of the volume scrub recording the given cleanup
+// job of the given persistent volume. Every job gets its own volume scrub,
+// since cleanup jobs are recreated with the same name when they are retried.
+func scrubName(pv *apiv1.PersistentVolume, job *batchv1.Job) string {
+	name := pv.Name
+	if len(name) > maxScrubNameLength {
+		name = name[:maxScrubNameLength]
+	}
+
+	uid := string(job.UID)
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+
+	return fmt.Sprintf("%s-%s", name, uid)
+}
+
+// previousClaim returns the claim the given volume was bound to before it got
+// released, as recorded when its recycling started.
+func previousClaim(pv *apiv1.PersistentVolume) v1alpha1.VolumeScrubSpecClaim {
+	namespace, name, ok := splitNamespacedName(getVolumeAnnotation(pv, previousClaimAnnotation))
+	if !ok {
+		return v1alpha1.VolumeScrubSpecClaim{}
+	}
+
+	return v1alpha1.VolumeScrubSpecClaim{
+		Name:      name,
+		Namespace: namespace,
+	}
+}
+
+// splitNamespacedName splits the given namespace/name pair.
+func splitNamespacedName(s string) (string, string, bool) {
+	parts := strings.SplitN(s, string(types.Separator), 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// ensureScrub creates the volume scrub recording the given cleanup job of the
+// volume, unless it exists already.
+func (r *Resource) ensureScrub(ctx context.Context, pv *apiv1.PersistentVolume, strategy string, job *batchv1.Job) error {
+	name := scrubName(pv, job)
+
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: name}, &v1alpha1.VolumeScrub{})
+	if err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+
+	var image string
+	for _, c := range job.Spec.Template.Spec.Containers {
+		image = c.Image
+	}
+
+	startedAt := job.CreationTimestamp
+	if startedAt.IsZero() {
+		startedAt = metav1.Now()
+	}
+
+	scrub := &v1alpha1.VolumeScrub{
+		TypeMeta: v1alpha1.NewVolumeScrubTypeMeta(),
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: v1alpha1.VolumeScrubSpec{
+			Attempt:       volumeRetries(pv) + 1,
+			Image:         image,
+			JobName:       job.Name,
+			PreviousClaim: previousClaim(pv),
+			StorageClass:  policy.StorageClass(pv),
+			Strategy:      strategy,
+			VolumeName:    pv.Name,
+		},
+	}
+
+	err = r.ctrlClient.Create(ctx, scrub)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// The status is ignored on creation, since it is a subresource.
+	scrub.Status = v1alpha1.VolumeScrubStatus{
+		Phase:     v1alpha1.VolumeScrubPhaseRunning,
+		StartedAt: &startedAt,
+	}
+
+	err = r.ctrlClient.Status().Update(ctx, scrub)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "created volume scrub", name)
+
+	return nil
+}
+
+// finishScrub records the outcome of the given cleanup job of the volume in
+// its volume scrub. Volume scrubs which already finished are left alone.
+func (r *Resource) finishScrub(ctx context.Context, pv *apiv1.PersistentVolume, job *batchv1.Job, status v1alpha1.VolumeScrubStatus) error {
+	scrub := &v1alpha1.VolumeScrub{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: scrubName(pv, job)}, scrub)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if scrub.Status.Phase != v1alpha1.VolumeScrubPhaseRunning {
+		return nil
+	}
+
+	completedAt := metav1.Now()
+	status.CompletedAt = &completedAt
+	status.StartedAt = scrub.Status.StartedAt
+	scrub.Status = status
+
+	err = r.ctrlClient.Status().Update(ctx, scrub)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "finished volume scrub", fmt.Sprintf("%s %s", scrub.Name, status.Phase))
+
+	return nil
+}
+
+// abortScrub marks the volume scrub of the current cleanup job of the volume
+// as failed, before the job gets removed without finishing.
+func (r *Resource) abortScrub(ctx context.Context, pv *apiv1.PersistentVolume, reason, message string) error {
+	job, err := r.k8sClient.BatchV1().Jobs(r.namespace).Get(jobName(pv), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	status := v1alpha1.VolumeScrubStatus{
+		Phase:   v1alpha1.VolumeScrubPhaseFailed,
+		Reason:  reason,
+		Message: message,
+	}
+
+	err = r.finishScrub(ctx, pv, job, status)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// lastTermination returns the state of the most recently terminated container
+// of the pods of the given job, or nil if none terminated yet.
+func (r *Resource) lastTermination(ctx context.Context, job *batchv1.Job) (*apiv1.ContainerStateTerminated, error) {
+	podSelector := labels.Set(map[string]string{jobLabel: job.Name})
+	listOptions := metav1.ListOptions{LabelSelector: podSelector.AsSelector().String()}
+	pods, err := r.k8sClient.CoreV1().Pods(r.namespace).List(listOptions)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var last *apiv1.ContainerStateTerminated
+	for _, p := range pods.Items {
+		for _, s := range p.Status.ContainerStatuses {
+			t := s.State.Terminated
+			if t == nil {
+				continue
+			}
+			if last != nil && t.FinishedAt.Time.Before(last.FinishedAt.Time) {
+				continue
+			}
+
+			last = t.DeepCopy()
+		}
+	}
+
+	return last, nil
+}
@@ -0,0 +1,226 @@
+package persistentvolume
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+)
+
+// newCtrlClient returns a fake controller-runtime client knowing about the
+// custom resources of the operator.
+func newCtrlClient(objects ...runtime.Object) client.Client {
+	scheme := runtime.NewScheme()
+	err := v1alpha1.AddToScheme(scheme)
+	if err != nil {
+		panic(err)
+	}
+
+	return ctrlfake.NewFakeClientWithScheme(scheme, objects...)
+}
+
+func Test_Resource_ensureScrub(t *testing.T) {
+	pv := &apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "TestPersistentVolume",
+			Annotations: map[string]string{
+				previousClaimAnnotation: "default/data-postgres-0",
+				retriesAnnotation:       "1",
+			},
+		},
+		Spec: apiv1.PersistentVolumeSpec{
+			StorageClassName: "local",
+		},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume",
+			Namespace: metav1.NamespaceSystem,
+			UID:       types.UID("6d1fd26a-0f5b-4e8c-9a3e-1c2b3d4e5f60"),
+		},
+		Spec: batchv1.JobSpec{
+			Template: apiv1.PodTemplateSpec{
+				Spec: apiv1.PodSpec{
+					Containers: []apiv1.Container{
+						{Image: "quay.io/giantswarm/alpine:3.11"},
+					},
+				},
+			},
+		},
+	}
+
+	ctrlClient := newCtrlClient()
+
+	var err error
+	var newResource *Resource
+	{
+		resourceConfig := Config{
+			Cleaners:      cleaner.Builtin(),
+			CtrlClient:    ctrlClient,
+			EventRecorder: &record.FakeRecorder{},
+			K8sClient:     fake.NewSimpleClientset(),
+			Logger:        microloggertest.New(),
+			Namespace:     metav1.NamespaceSystem,
+			Policy:        &policy.Policy{},
+		}
+		newResource, err = New(resourceConfig)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	// Ensuring twice must not fail, since the scrub is ensured during every
+	// reconciliation while the job runs.
+	for i := 0; i < 2; i++ {
+		err = newResource.ensureScrub(context.TODO(), pv, cleaner.DeleteFiles, job)
+		if err != nil {
+			t.Fatalf("unexpected error returned ensuring scrub: %s\n", err)
+		}
+	}
+
+	scrub := &v1alpha1.VolumeScrub{}
+	err = ctrlClient.Get(context.TODO(), types.NamespacedName{Name: "TestPersistentVolume-6d1fd26a"}, scrub)
+	if err != nil {
+		t.Fatalf("unexpected error returned getting scrub: %s\n", err)
+	}
+
+	expectedSpec := v1alpha1.VolumeScrubSpec{
+		Attempt: 2,
+		Image:   "quay.io/giantswarm/alpine:3.11",
+		JobName: job.Name,
+		PreviousClaim: v1alpha1.VolumeScrubSpecClaim{
+			Name:      "data-postgres-0",
+			Namespace: "default",
+		},
+		StorageClass: "local",
+		Strategy:     cleaner.DeleteFiles,
+		VolumeName:   pv.Name,
+	}
+	if scrub.Spec != expectedSpec {
+		t.Fatalf("expected spec %#v got %#v", expectedSpec, scrub.Spec)
+	}
+	if scrub.Status.Phase != v1alpha1.VolumeScrubPhaseRunning {
+		t.Fatalf("expected phase %#q got %#q", v1alpha1.VolumeScrubPhaseRunning, scrub.Status.Phase)
+	}
+	if scrub.Status.StartedAt == nil {
+		t.Fatalf("expected start time got none")
+	}
+}
+
+func Test_Resource_finishScrub(t *testing.T) {
+	var exitCode int32 = 0
+	var bytesRemoved int64 = 4096
+
+	testCases := []struct {
+		description   string
+		phase         v1alpha1.VolumeScrubPhase
+		status        v1alpha1.VolumeScrubStatus
+		expectedPhase v1alpha1.VolumeScrubPhase
+		expectedBytes *int64
+	}{
+		{
+			description: "running scrub records the outcome",
+			phase:       v1alpha1.VolumeScrubPhaseRunning,
+			status: v1alpha1.VolumeScrubStatus{
+				BytesRemoved: &bytesRemoved,
+				ExitCode:     &exitCode,
+				Phase:        v1alpha1.VolumeScrubPhaseSucceeded,
+			},
+			expectedPhase: v1alpha1.VolumeScrubPhaseSucceeded,
+			expectedBytes: &bytesRemoved,
+		},
+		{
+			description: "finished scrub is left alone",
+			phase:       v1alpha1.VolumeScrubPhaseFailed,
+			status: v1alpha1.VolumeScrubStatus{
+				BytesRemoved: &bytesRemoved,
+				ExitCode:     &exitCode,
+				Phase:        v1alpha1.VolumeScrubPhaseSucceeded,
+			},
+			expectedPhase: v1alpha1.VolumeScrubPhaseFailed,
+			expectedBytes: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume",
+				},
+			}
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume",
+					Namespace: metav1.NamespaceSystem,
+					UID:       types.UID("6d1fd26a-0f5b-4e8c-9a3e-1c2b3d4e5f60"),
+				},
+			}
+			startedAt := metav1.Now()
+			scrub := &v1alpha1.VolumeScrub{
+				TypeMeta: v1alpha1.NewVolumeScrubTypeMeta(),
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume-6d1fd26a",
+				},
+				Status: v1alpha1.VolumeScrubStatus{
+					Phase:     tc.phase,
+					StartedAt: &startedAt,
+				},
+			}
+
+			ctrlClient := newCtrlClient(scrub)
+
+			var err error
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					Cleaners:      cleaner.Builtin(),
+					CtrlClient:    ctrlClient,
+					EventRecorder: &record.FakeRecorder{},
+					K8sClient:     fake.NewSimpleClientset(),
+					Logger:        microloggertest.New(),
+					Namespace:     metav1.NamespaceSystem,
+					Policy:        &policy.Policy{},
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			err = newResource.finishScrub(context.TODO(), pv, job, tc.status)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned finishing scrub: %s\n", i+1, err)
+			}
+
+			result := &v1alpha1.VolumeScrub{}
+			err = ctrlClient.Get(context.TODO(), types.NamespacedName{Name: scrub.Name}, result)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting scrub: %s\n", i+1, err)
+			}
+
+			if result.Status.Phase != tc.expectedPhase {
+				t.Fatalf("case %d expected phase %#q got %#q", i+1, tc.expectedPhase, result.Status.Phase)
+			}
+			if (result.Status.BytesRemoved == nil) != (tc.expectedBytes == nil) || (tc.expectedBytes != nil && *result.Status.BytesRemoved != *tc.expectedBytes) {
+				t.Fatalf("case %d expected bytes removed %v got %v", i+1, tc.expectedBytes, result.Status.BytesRemoved)
+			}
+			if result.Status.StartedAt == nil {
+				t.Fatalf("case %d expected start time to be kept", i+1)
+			}
+		})
+	}
+}
@@ -3,9 +3,12 @@ package persistentvolume
 import apiv1 "k8s.io/api/core/v1"
 
 // RecyclePersistentVolume reflects PersistentVolume
-// with additional RecycleState.
+// with additional RecycleState and pending Command.
 type RecyclePersistentVolume struct {
 	Name         string
 	State        apiv1.PersistentVolumePhase
 	RecycleState string
+	Command      string
+	// Policy is the name of the cleanup policy selecting the volume.
+	Policy string
 }
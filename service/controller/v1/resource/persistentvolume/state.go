@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
 )
 
 // GetCurrentState returns current state of the recycled persistent volume.
@@ -19,6 +21,8 @@ func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interf
 		Name:         pv.Name,
 		State:        pv.Status.Phase,
 		RecycleState: recycleState,
+		Command:      pv.Annotations[recycle.CommandAnnotation],
+		Policy:       pv.Annotations[cleanupPolicyAnnotation],
 	}
 
 	return rpv, nil
@@ -36,6 +40,7 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 		Name:         pv.Name,
 		State:        "Available",
 		RecycleState: recycled,
+		Policy:       r.desiredPolicy(pv),
 	}
 
 	return rpv, nil
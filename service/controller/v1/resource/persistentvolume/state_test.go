@@ -9,6 +9,10 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
 )
 
 func Test_Resource_RecyclePersistentVolume_GetCurrentState(t *testing.T) {
@@ -61,8 +65,13 @@ func Test_Resource_RecyclePersistentVolume_GetCurrentState(t *testing.T) {
 	var newResource *Resource
 	{
 		resourceConfig := Config{
-			K8sClient: fake.NewSimpleClientset(),
-			Logger:    microloggertest.New(),
+			Cleaners:      cleaner.Builtin(),
+			CtrlClient:    newCtrlClient(),
+			EventRecorder: &record.FakeRecorder{},
+			K8sClient:     fake.NewSimpleClientset(),
+			Logger:        microloggertest.New(),
+			Namespace:     metav1.NamespaceSystem,
+			Policy:        &policy.Policy{},
 		}
 		newResource, err = New(resourceConfig)
 		if err != nil {
@@ -107,14 +116,79 @@ func Test_Resource_RecyclePersistentVolume_GetDesiredState(t *testing.T) {
 				RecycleState: recycled,
 			},
 		},
+		{
+			description: "released volume selected by cleanup policy, expected recycle volume with cleanup policy",
+			obj: &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume",
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					ClaimRef: &apiv1.ObjectReference{
+						Name:      "data",
+						Namespace: "tenant",
+					},
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: "Released",
+				},
+			},
+			expectedRecyclePersistentVolume: &RecyclePersistentVolume{
+				Name:         "TestPersistentVolume",
+				State:        "Available",
+				RecycleState: recycled,
+				Policy:       "tenant",
+			},
+		},
+		{
+			description: "volume being cleaned keeps its cleanup policy, expected recycle volume with recorded cleanup policy",
+			obj: &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume",
+					Annotations: map[string]string{
+						"pv-cleaner-operator.giantswarm.io/cleanup-policy":       "removed",
+						"pv-cleaner-operator.giantswarm.io/volume-recycle-state": cleaning,
+					},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					ClaimRef: &apiv1.ObjectReference{
+						Name:      "data",
+						Namespace: "tenant",
+					},
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: "Bound",
+				},
+			},
+			expectedRecyclePersistentVolume: &RecyclePersistentVolume{
+				Name:         "TestPersistentVolume",
+				State:        "Available",
+				RecycleState: recycled,
+				Policy:       "removed",
+			},
+		},
 	}
 
+	p := &policy.Policy{}
+	p.SetRules([]policy.Rule{
+		{
+			Name: "tenant",
+			Selector: policy.Selector{
+				ClaimNamespaces: []string{"tenant"},
+			},
+		},
+	})
+
 	var err error
 	var newResource *Resource
 	{
 		resourceConfig := Config{
-			K8sClient: fake.NewSimpleClientset(),
-			Logger:    microloggertest.New(),
+			Cleaners:      cleaner.Builtin(),
+			CtrlClient:    newCtrlClient(),
+			EventRecorder: &record.FakeRecorder{},
+			K8sClient:     fake.NewSimpleClientset(),
+			Logger:        microloggertest.New(),
+			Namespace:     metav1.NamespaceSystem,
+			Policy:        p,
 		}
 		newResource, err = New(resourceConfig)
 		if err != nil {
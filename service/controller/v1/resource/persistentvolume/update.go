@@ -2,19 +2,12 @@ package persistentvolume
 
 import (
 	"context"
-	"fmt"
 	"reflect"
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/operatorkit/resource/crud"
-	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
-)
 
-const (
-	pvcTerminatingPhase = "Terminating"
-	jobLabel            = "job-name"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
 )
 
 // NewUpdatePatch returns patch to apply on updated persistent volume.
@@ -32,14 +25,16 @@ func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desire
 }
 
 // ApplyUpdateChange represents update patch logic.
-// All actions are based on combination of volume state
-// and custom recycle state.
-//   * ReleasedRecycled - initial state of volume after claim is deleted; volume is recreated at this step
+// All actions are based on combination of volume phase and custom recycle
+// state, as defined by the transitions of the recycle package.
+//   * ReleasedRecycled - initial state of volume after claim is deleted; volume is queued at this step
+//   * ReleasedQueued - volume waits for a free cleanup slot; volume is recreated at this step
 //   * AvailableCleaning - volume ready for bounding to cleanup claim
 //   * BoundCleaning - volume claim is ready for mounting into cleanup job
 //   * BoundTeardown - waiting for leftovers to be cleaned up
-//   * ReleasedCleaning - volume claim was succesfully cleaned up, volume can be recreated
+//   * ReleasedTeardown - volume claim was succesfully cleaned up, volume can be recreated
 //   * AvailableRecycled - desired state of the volume
+//   * Failed - volume could not be recycled and needs manual intervention
 func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateState interface{}) error {
 	rpv, err := toRecyclePV(updateState)
 	if err != nil {
@@ -56,79 +51,63 @@ func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateState inter
 		return microerror.Mask(err)
 	}
 
-	switch combinedState := string(rpv.State) + rpv.RecycleState; combinedState {
-	case "Released":
-		fallthrough
-	case "ReleasedRecycled":
-		pv, err := r.newRecycleStateAnnotation(pv, cleaning)
-		_, err = r.k8sClient.CoreV1().PersistentVolumes().Update(pv)
-		if err != nil {
-			return microerror.Mask(err)
-		}
-	case "AvailableCleaning":
-		pvcdef := newPvc(pv)
-		_, err := r.k8sClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceSystem).Create(pvcdef)
-		if errors.IsAlreadyExists(err) {
-			return nil
-		}
-		if err != nil {
-			return microerror.Mask(err)
-		}
-	case "BoundCleaning":
-		pvcName := fmt.Sprintf("pv-cleaner-claim-%s", pv.Name)
-		pvc, err := r.k8sClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceSystem).Get(pvcName, metav1.GetOptions{})
-		if err != nil {
-			return microerror.Mask(err)
-		}
-
-		if pvc.ObjectMeta.DeletionTimestamp != nil {
-			r.logger.LogCtx(ctx, "pvc", pvcName, "waiting for pvc to release a pv", pv.Name)
-			return nil
-		}
+	handled, err := r.ensurePolicy(ctx, pv)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if handled {
+		return nil
+	}
 
-		cleanupJobDef := newCleanupJob(pvc)
-		cleanupJob, err := r.k8sClient.BatchV1().Jobs(metav1.NamespaceSystem).Create(cleanupJobDef)
-		if errors.IsAlreadyExists(err) {
-			cleanupJob, err = r.k8sClient.BatchV1().Jobs(metav1.NamespaceSystem).Get(cleanupJobDef.Name, metav1.GetOptions{})
-			if err != nil {
-				return microerror.Mask(err)
-			}
-		} else if err != nil {
-			return microerror.Mask(err)
-		}
+	// Commands are applied before the transition is looked up, so that
+	// volumes in an unknown recycle state can be recycled or skipped.
+	handled, err = r.applyCommand(ctx, pv)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if handled {
+		return nil
+	}
 
-		if cleanupJob.Status.Succeeded != 1 {
-			r.logger.LogCtx(ctx, "job", cleanupJob.Name, "waiting for job to complete cleanup of pv", pv.Name)
-			return nil
-		}
+	transition, err := recycle.NextTransition(rpv.State, recycle.State(rpv.RecycleState))
+	if err != nil {
+		return microerror.Mask(err)
+	}
 
-		err = r.k8sClient.BatchV1().Jobs(metav1.NamespaceSystem).Delete(cleanupJob.Name, &metav1.DeleteOptions{})
-		if err != nil {
-			return microerror.Mask(err)
-		}
+	handled, err = r.ensureDeadline(ctx, pv, transition)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if handled {
+		return nil
+	}
 
-		podSelector := labels.Set(map[string]string{jobLabel: cleanupJob.Name})
-		listOptions := metav1.ListOptions{LabelSelector: podSelector.AsSelector().String()}
-		err = r.k8sClient.CoreV1().Pods(metav1.NamespaceSystem).DeleteCollection(&metav1.DeleteOptions{}, listOptions)
+	if transition.Action == recycle.CreateClaim || transition.Action == recycle.RunJob {
+		handled, err = r.ensureNode(ctx, pv)
 		if err != nil {
 			return microerror.Mask(err)
 		}
-
-		if err := r.k8sClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceSystem).Delete(pvcName, &metav1.DeleteOptions{}); err != nil {
-			return microerror.Mask(err)
+		if handled {
+			return nil
 		}
+	}
 
-		pv, err := r.newRecycleStateAnnotation(pv, teardown)
-		_, err = r.k8sClient.CoreV1().PersistentVolumes().Update(pv)
-		if err != nil {
-			return microerror.Mask(err)
-		}
-	case "ReleasedTeardown":
-		pv, err := r.newRecycleStateAnnotation(pv, recycled)
-		_, err = r.k8sClient.CoreV1().PersistentVolumes().Update(pv)
-		if err != nil {
-			return microerror.Mask(err)
-		}
+	switch transition.Action {
+	case recycle.StartCleaning:
+		err = r.startCleaning(ctx, pv, transition)
+	case recycle.Admit:
+		err = r.admit(ctx, pv, transition)
+	case recycle.CreateClaim:
+		err = r.createClaim(ctx, pv)
+	case recycle.RunJob:
+		err = r.runJob(ctx, pv, transition)
+	case recycle.FinishRecycle:
+		err = r.updateRecycleState(ctx, pv, transition.Next)
+	case recycle.Fail:
+		err = r.markFailed(ctx, pv, volumeFailedReason, pv.Status.Message)
+	}
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
 	return nil
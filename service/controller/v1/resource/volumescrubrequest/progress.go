@@ -0,0 +1,91 @@
+package volumescrubrequest
+
+import (
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+// progress returns the phase and message of the accepted scrub request based
+// on the recycle state of its volume. Volumes which are recycled or failed
+// finished their cleanup only if their recycle state changed since the
+// request was accepted. Otherwise the recycle command was rejected by the
+// persistent volume controller, e.g. because the volume got bound in the
+// meantime.
+func progress(req *v1alpha1.VolumeScrubRequest, pv *apiv1.PersistentVolume) (v1alpha1.VolumeScrubRequestPhase, string) {
+	if isRequestedBy(pv, req) {
+		return v1alpha1.VolumeScrubRequestPhasePending, "requested cleanup of the volume"
+	}
+
+	state := recycle.State(recycleState(pv))
+	switch state {
+	case recycle.Queued:
+		return v1alpha1.VolumeScrubRequestPhaseRunning, "volume waits for a free cleanup slot"
+	case recycle.Cleaning:
+		return v1alpha1.VolumeScrubRequestPhaseRunning, "volume is being scrubbed"
+	case recycle.Teardown:
+		return v1alpha1.VolumeScrubRequestPhaseRunning, "volume was scrubbed, cleanup claim is being removed"
+	}
+
+	if !changedSinceAccepted(req, pv) {
+		return v1alpha1.VolumeScrubRequestPhaseFailed, "recycle command was rejected by the operator, see the events of the volume"
+	}
+
+	if state == recycle.Failed {
+		return v1alpha1.VolumeScrubRequestPhaseFailed, fmt.Sprintf("%s: %s", pv.Annotations[recycle.FailureReasonAnnotation], pv.Annotations[recycle.FailureMessageAnnotation])
+	}
+
+	return v1alpha1.VolumeScrubRequestPhaseSucceeded, "volume was scrubbed"
+}
+
+// changedSinceAccepted returns true if the recycle state of the volume
+// changed since the scrub request was accepted.
+func changedSinceAccepted(req *v1alpha1.VolumeScrubRequest, pv *apiv1.PersistentVolume) bool {
+	if req.Status.AcceptedAt == nil {
+		return false
+	}
+
+	changedAt, err := time.Parse(time.RFC3339, pv.Annotations[recycle.StateChangedAtAnnotation])
+	if err != nil {
+		return false
+	}
+
+	return !changedAt.Before(req.Status.AcceptedAt.Time.Truncate(time.Second))
+}
+
+// isFinished returns true if the given phase is final.
+func isFinished(phase v1alpha1.VolumeScrubRequestPhase) bool {
+	switch phase {
+	case v1alpha1.VolumeScrubRequestPhaseFailed, v1alpha1.VolumeScrubRequestPhaseRejected, v1alpha1.VolumeScrubRequestPhaseSucceeded:
+		return true
+	}
+
+	return false
+}
+
+// isRequestedBy returns true if the recycle command of the given scrub
+// request is pending on the volume.
+func isRequestedBy(pv *apiv1.PersistentVolume, req *v1alpha1.VolumeScrubRequest) bool {
+	return pv.Annotations[recycle.CommandAnnotation] == string(recycle.Recycle) && pv.Annotations[recycle.CommandRequestedByAnnotation] == requester(req)
+}
+
+// recycleState returns the recycle state of the volume. Volumes without
+// recycle state are recycled.
+func recycleState(pv *apiv1.PersistentVolume) string {
+	state := pv.Annotations[recycle.StateAnnotation]
+	if state == "" {
+		return string(recycle.Recycled)
+	}
+
+	return state
+}
+
+// requester returns the name recorded as requester of the recycle command of
+// the given scrub request.
+func requester(req *v1alpha1.VolumeScrubRequest) string {
+	return fmt.Sprintf("volumescrubrequest/%s", req.Name)
+}
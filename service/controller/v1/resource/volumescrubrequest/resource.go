@@ -0,0 +1,249 @@
+package volumescrubrequest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+const (
+	name = "volumescrubrequest"
+)
+
+// Config describes resource configuration.
+type Config struct {
+	// CtrlClient is used to update the status of scrub requests.
+	CtrlClient client.Client
+	K8sClient  kubernetes.Interface
+	Logger     micrologger.Logger
+	// Handles returns true for the persistent volumes managed by the
+	// operator. Only these volumes can be scrubbed on demand.
+	Handles func(pv *apiv1.PersistentVolume) bool
+}
+
+// Resource requests the cleanup of the volumes of scrub requests and reports
+// the progress of the cleanup in the status of the requests.
+type Resource struct {
+	ctrlClient client.Client
+	k8sClient  kubernetes.Interface
+	logger     micrologger.Logger
+	handles    func(pv *apiv1.PersistentVolume) bool
+}
+
+// New is factory for resource objects.
+func New(config Config) (*Resource, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.CtrlClient must not be empty")
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+	if config.Handles == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Handles must not be empty")
+	}
+
+	r := &Resource{
+		ctrlClient: config.CtrlClient,
+		k8sClient:  config.K8sClient,
+		logger:     config.Logger,
+		handles:    config.Handles,
+	}
+
+	return r, nil
+}
+
+// Name returns name of the managed resource.
+func (r *Resource) Name() string {
+	return name
+}
+
+// EnsureCreated requests the cleanup of the volume of new scrub requests by
+// issuing the recycle command on the volume, which is applied by the
+// persistent volume controller. Accepted requests follow the recycle state of
+// their volume until it got scrubbed or failed.
+func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	req, err := toVolumeScrubRequest(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if isFinished(req.Status.Phase) {
+		return nil
+	}
+
+	pv, err := r.k8sClient.CoreV1().PersistentVolumes().Get(req.Spec.VolumeName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		phase := v1alpha1.VolumeScrubRequestPhaseRejected
+		if req.Status.Phase != "" {
+			phase = v1alpha1.VolumeScrubRequestPhaseFailed
+		}
+
+		err = r.updateStatus(ctx, req, phase, "", fmt.Sprintf("persistent volume %#q not found", req.Spec.VolumeName))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if req.Status.Phase == "" {
+		err = r.accept(ctx, req, pv)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	phase, message := progress(req, pv)
+
+	err = r.updateStatus(ctx, req, phase, recycleState(pv), message)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// EnsureDeleted withdraws the recycle command of deleted scrub requests,
+// unless the persistent volume controller picked it up already.
+func (r *Resource) EnsureDeleted(ctx context.Context, obj interface{}) error {
+	req, err := toVolumeScrubRequest(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	pv, err := r.k8sClient.CoreV1().PersistentVolumes().Get(req.Spec.VolumeName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !isRequestedBy(pv, req) {
+		return nil
+	}
+
+	delete(pv.Annotations, recycle.CommandAnnotation)
+	delete(pv.Annotations, recycle.CommandRequestedByAnnotation)
+
+	_, err = r.k8sClient.CoreV1().PersistentVolumes().Update(pv)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "withdrew command of deleted scrub request", req.Name)
+
+	return nil
+}
+
+// accept checks whether the volume of the new scrub request can be scrubbed
+// and issues the recycle command on it. Requests for volumes which are not
+// managed by the operator, or which are not available or released, are
+// rejected. Requests for volumes with another pending command wait for the
+// command to be applied. Requests whose command was issued already, but whose
+// status could not be updated, only get their status recorded.
+func (r *Resource) accept(ctx context.Context, req *v1alpha1.VolumeScrubRequest, pv *apiv1.PersistentVolume) error {
+	if !r.handles(pv) {
+		err := r.updateStatus(ctx, req, v1alpha1.VolumeScrubRequestPhaseRejected, "", fmt.Sprintf("persistent volume %#q is not managed by the operator", pv.Name))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	state := recycleState(pv)
+
+	err := recycle.CheckCommand(pv.Status.Phase, recycle.State(state), recycle.Recycle)
+	if err != nil {
+		err = r.updateStatus(ctx, req, v1alpha1.VolumeScrubRequestPhaseRejected, state, err.Error())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	if !isRequestedBy(pv, req) {
+		if command := pv.Annotations[recycle.CommandAnnotation]; command != "" {
+			r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "waiting for pending command to be applied", command)
+			return nil
+		}
+
+		if pv.Annotations == nil {
+			pv.Annotations = map[string]string{}
+		}
+		pv.Annotations[recycle.CommandAnnotation] = string(recycle.Recycle)
+		pv.Annotations[recycle.CommandRequestedByAnnotation] = requester(req)
+
+		_, err = r.k8sClient.CoreV1().PersistentVolumes().Update(pv)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	acceptedAt := metav1.NewTime(time.Now().UTC().Truncate(time.Second))
+	req.Status.AcceptedAt = &acceptedAt
+
+	err = r.updateStatus(ctx, req, v1alpha1.VolumeScrubRequestPhasePending, state, "requested cleanup of the volume")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "persistentvolume", pv.Name, "accepted scrub request", req.Name)
+
+	return nil
+}
+
+// updateStatus updates the status of the scrub request if it changed.
+// Requests entering a final phase get their completion time recorded.
+func (r *Resource) updateStatus(ctx context.Context, req *v1alpha1.VolumeScrubRequest, phase v1alpha1.VolumeScrubRequestPhase, state, message string) error {
+	if req.Status.Phase == phase && req.Status.RecycleState == state && req.Status.Message == message {
+		return nil
+	}
+
+	req.Status.Phase = phase
+	req.Status.RecycleState = state
+	req.Status.Message = message
+	if isFinished(phase) {
+		completedAt := metav1.Now()
+		req.Status.CompletedAt = &completedAt
+	}
+
+	err := r.ctrlClient.Status().Update(ctx, req)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "volumescrubrequest", req.Name, "updated status", fmt.Sprintf("%s %s", phase, message))
+
+	return nil
+}
+
+// toVolumeScrubRequest converts interface object into VolumeScrubRequest
+// object.
+func toVolumeScrubRequest(v interface{}) (*v1alpha1.VolumeScrubRequest, error) {
+	req, ok := v.(*v1alpha1.VolumeScrubRequest)
+	if !ok {
+		return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", &v1alpha1.VolumeScrubRequest{}, v)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,248 @@
+package volumescrubrequest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Resource_EnsureCreated(t *testing.T) {
+	acceptedAt := metav1.NewTime(time.Now().Add(-time.Hour).UTC().Truncate(time.Second))
+	before := acceptedAt.Add(-time.Hour).Format(time.RFC3339)
+	after := acceptedAt.Add(time.Minute).Format(time.RFC3339)
+
+	testCases := []struct {
+		description     string
+		phase           v1alpha1.VolumeScrubRequestPhase
+		volumeLabels    map[string]string
+		volumePhase     apiv1.PersistentVolumePhase
+		annotations     map[string]string
+		expectedPhase   v1alpha1.VolumeScrubRequestPhase
+		expectedState   string
+		expectedCommand string
+	}{
+		{
+			description:     "request for released volume is accepted",
+			phase:           "",
+			volumeLabels:    map[string]string{"cleanup": "true"},
+			volumePhase:     apiv1.VolumeReleased,
+			annotations:     map[string]string{},
+			expectedPhase:   v1alpha1.VolumeScrubRequestPhasePending,
+			expectedState:   string(recycle.Recycled),
+			expectedCommand: string(recycle.Recycle),
+		},
+		{
+			description:     "request for available volume is accepted",
+			phase:           "",
+			volumeLabels:    map[string]string{"cleanup": "true"},
+			volumePhase:     apiv1.VolumeAvailable,
+			annotations:     map[string]string{recycle.StateAnnotation: string(recycle.Recycled)},
+			expectedPhase:   v1alpha1.VolumeScrubRequestPhasePending,
+			expectedState:   string(recycle.Recycled),
+			expectedCommand: string(recycle.Recycle),
+		},
+		{
+			description:     "request for unmanaged volume is rejected",
+			phase:           "",
+			volumeLabels:    map[string]string{},
+			volumePhase:     apiv1.VolumeAvailable,
+			annotations:     map[string]string{},
+			expectedPhase:   v1alpha1.VolumeScrubRequestPhaseRejected,
+			expectedState:   "",
+			expectedCommand: "",
+		},
+		{
+			description:     "request for bound volume is rejected",
+			phase:           "",
+			volumeLabels:    map[string]string{"cleanup": "true"},
+			volumePhase:     apiv1.VolumeBound,
+			annotations:     map[string]string{},
+			expectedPhase:   v1alpha1.VolumeScrubRequestPhaseRejected,
+			expectedState:   string(recycle.Recycled),
+			expectedCommand: "",
+		},
+		{
+			description:  "request for volume with pending command waits",
+			phase:        "",
+			volumeLabels: map[string]string{"cleanup": "true"},
+			volumePhase:  apiv1.VolumeReleased,
+			annotations: map[string]string{
+				recycle.CommandAnnotation:            string(recycle.Skip),
+				recycle.CommandRequestedByAnnotation: "jane@example.com",
+			},
+			expectedPhase:   "",
+			expectedState:   "",
+			expectedCommand: string(recycle.Skip),
+		},
+		{
+			description:  "request whose command was issued already is pending",
+			phase:        "",
+			volumeLabels: map[string]string{"cleanup": "true"},
+			volumePhase:  apiv1.VolumeReleased,
+			annotations: map[string]string{
+				recycle.CommandAnnotation:            string(recycle.Recycle),
+				recycle.CommandRequestedByAnnotation: "volumescrubrequest/wipe-before-handover",
+			},
+			expectedPhase:   v1alpha1.VolumeScrubRequestPhasePending,
+			expectedState:   string(recycle.Recycled),
+			expectedCommand: string(recycle.Recycle),
+		},
+		{
+			description:  "pending request runs once the volume is cleaned",
+			phase:        v1alpha1.VolumeScrubRequestPhasePending,
+			volumeLabels: map[string]string{"cleanup": "true"},
+			volumePhase:  apiv1.VolumeBound,
+			annotations: map[string]string{
+				recycle.StateAnnotation:          string(recycle.Cleaning),
+				recycle.StateChangedAtAnnotation: after,
+			},
+			expectedPhase:   v1alpha1.VolumeScrubRequestPhaseRunning,
+			expectedState:   string(recycle.Cleaning),
+			expectedCommand: "",
+		},
+		{
+			description:  "running request succeeds once the volume is recycled",
+			phase:        v1alpha1.VolumeScrubRequestPhaseRunning,
+			volumeLabels: map[string]string{"cleanup": "true"},
+			volumePhase:  apiv1.VolumeAvailable,
+			annotations: map[string]string{
+				recycle.StateAnnotation:          string(recycle.Recycled),
+				recycle.StateChangedAtAnnotation: after,
+			},
+			expectedPhase:   v1alpha1.VolumeScrubRequestPhaseSucceeded,
+			expectedState:   string(recycle.Recycled),
+			expectedCommand: "",
+		},
+		{
+			description:  "running request fails once the volume failed",
+			phase:        v1alpha1.VolumeScrubRequestPhaseRunning,
+			volumeLabels: map[string]string{"cleanup": "true"},
+			volumePhase:  apiv1.VolumeReleased,
+			annotations: map[string]string{
+				recycle.StateAnnotation:          string(recycle.Failed),
+				recycle.StateChangedAtAnnotation: after,
+				recycle.FailureReasonAnnotation:  "BackoffLimitExceeded",
+			},
+			expectedPhase:   v1alpha1.VolumeScrubRequestPhaseFailed,
+			expectedState:   string(recycle.Failed),
+			expectedCommand: "",
+		},
+		{
+			description:  "pending request fails when the command was rejected",
+			phase:        v1alpha1.VolumeScrubRequestPhasePending,
+			volumeLabels: map[string]string{"cleanup": "true"},
+			volumePhase:  apiv1.VolumeBound,
+			annotations: map[string]string{
+				recycle.StateAnnotation:          string(recycle.Recycled),
+				recycle.StateChangedAtAnnotation: before,
+			},
+			expectedPhase:   v1alpha1.VolumeScrubRequestPhaseFailed,
+			expectedState:   string(recycle.Recycled),
+			expectedCommand: "",
+		},
+		{
+			description:     "finished request is left alone",
+			phase:           v1alpha1.VolumeScrubRequestPhaseSucceeded,
+			volumeLabels:    map[string]string{"cleanup": "true"},
+			volumePhase:     apiv1.VolumeReleased,
+			annotations:     map[string]string{},
+			expectedPhase:   v1alpha1.VolumeScrubRequestPhaseSucceeded,
+			expectedState:   "",
+			expectedCommand: "",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "TestPersistentVolume",
+					Labels:      tc.volumeLabels,
+					Annotations: tc.annotations,
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: tc.volumePhase,
+				},
+			}
+			req := &v1alpha1.VolumeScrubRequest{
+				TypeMeta: v1alpha1.NewVolumeScrubRequestTypeMeta(),
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "wipe-before-handover",
+				},
+				Spec: v1alpha1.VolumeScrubRequestSpec{
+					VolumeName: pv.Name,
+				},
+			}
+			if tc.phase != "" {
+				req.Status.Phase = tc.phase
+				req.Status.AcceptedAt = &acceptedAt
+			}
+
+			scheme := runtime.NewScheme()
+			err := v1alpha1.AddToScheme(scheme)
+			if err != nil {
+				t.Fatal("expected", nil, "got", err)
+			}
+
+			k8sClient := fake.NewSimpleClientset(pv)
+			ctrlClient := ctrlfake.NewFakeClientWithScheme(scheme, req)
+
+			var newResource *Resource
+			{
+				resourceConfig := Config{
+					CtrlClient: ctrlClient,
+					K8sClient:  k8sClient,
+					Logger:     microloggertest.New(),
+					Handles: func(pv *apiv1.PersistentVolume) bool {
+						return pv.Labels["cleanup"] == "true"
+					},
+				}
+				newResource, err = New(resourceConfig)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			err = newResource.EnsureCreated(context.TODO(), req.DeepCopy())
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned ensuring request: %s\n", i+1, err)
+			}
+
+			result := &v1alpha1.VolumeScrubRequest{}
+			err = ctrlClient.Get(context.TODO(), types.NamespacedName{Name: req.Name}, result)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting request: %s\n", i+1, err)
+			}
+
+			if result.Status.Phase != tc.expectedPhase {
+				t.Fatalf("case %d expected phase %#q got %#q", i+1, tc.expectedPhase, result.Status.Phase)
+			}
+			if result.Status.RecycleState != tc.expectedState {
+				t.Fatalf("case %d expected recycle state %#q got %#q", i+1, tc.expectedState, result.Status.RecycleState)
+			}
+			if isFinished(tc.expectedPhase) && tc.phase != tc.expectedPhase && result.Status.CompletedAt == nil {
+				t.Fatalf("case %d expected completion time got none", i+1)
+			}
+
+			pv, err = k8sClient.CoreV1().PersistentVolumes().Get(pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned getting volume: %s\n", i+1, err)
+			}
+
+			if pv.Annotations[recycle.CommandAnnotation] != tc.expectedCommand {
+				t.Fatalf("case %d expected command %#q got %#q", i+1, tc.expectedCommand, pv.Annotations[recycle.CommandAnnotation])
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"fmt"
+
 	"github.com/giantswarm/k8sclient"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
@@ -9,7 +11,11 @@ import (
 	"github.com/giantswarm/operatorkit/resource/crud"
 	"github.com/giantswarm/operatorkit/resource/wrapper/metricsresource"
 	"github.com/giantswarm/operatorkit/resource/wrapper/retryresource"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
 	"github.com/giantswarm/pv-cleaner-operator/service/controller/v1/resource/persistentvolume"
 )
 
@@ -18,22 +24,35 @@ const (
 )
 
 type ResourceSetConfig struct {
-	K8sClient k8sclient.Interface
-	Logger    micrologger.Logger
+	Container     cleaner.ContainerConfig
+	EventRecorder record.EventRecorder
+	K8sClient     k8sclient.Interface
+	Logger        micrologger.Logger
+	Policy        *policy.Policy
 
+	Namespace   string
 	ProjectName string
 }
 
 func NewResourceSet(config ResourceSetConfig) (*controller.ResourceSet, error) {
 	var err error
 
+	if config.EventRecorder == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.EventRecorder must not be empty")
+	}
 	if config.K8sClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
 	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
 	}
+	if config.Policy == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Policy must not be empty")
+	}
 
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.Namespace must not be empty")
+	}
 	if config.ProjectName == "" {
 		return nil, microerror.Maskf(invalidConfigError, "config.ProjectName must not be empty")
 	}
@@ -41,8 +60,15 @@ func NewResourceSet(config ResourceSetConfig) (*controller.ResourceSet, error) {
 	var persistentVolumeResource resource.Interface
 	{
 		c := persistentvolume.Config{
-			K8sClient: config.K8sClient.K8sClient(),
-			Logger:    config.Logger,
+			Cleaners:      cleaner.Builtin(),
+			Container:     config.Container,
+			CtrlClient:    config.K8sClient.CtrlClient(),
+			EventRecorder: config.EventRecorder,
+			Finalizer:     controllerFinalizer(config.ProjectName),
+			K8sClient:     config.K8sClient.K8sClient(),
+			Logger:        config.Logger,
+			Namespace:     config.Namespace,
+			Policy:        config.Policy,
 		}
 
 		ops, err := persistentvolume.New(c)
@@ -79,8 +105,18 @@ func NewResourceSet(config ResourceSetConfig) (*controller.ResourceSet, error) {
 		}
 	}
 
+	resources = wrapSerial(resources)
+
+	// Volumes are handled if they carry the cleanup label or are selected by
+	// a cleanup policy. Other volumes are left alone, so that they do not get
+	// finalizers added.
 	handlesFunc := func(obj interface{}) bool {
-		return true
+		pv, ok := obj.(*apiv1.PersistentVolume)
+		if !ok {
+			return false
+		}
+
+		return persistentvolume.Handles(config.Policy, pv)
 	}
 
 	var resourceSet *controller.ResourceSet
@@ -100,6 +136,12 @@ func NewResourceSet(config ResourceSetConfig) (*controller.ResourceSet, error) {
 	return resourceSet, nil
 }
 
+// controllerFinalizer returns the finalizer operatorkit adds to the objects
+// handled by the controller with the given name.
+func controllerFinalizer(name string) string {
+	return fmt.Sprintf("operatorkit.giantswarm.io/%s", name)
+}
+
 func toCRUDResource(logger micrologger.Logger, ops crud.Interface) (*crud.Resource, error) {
 	c := crud.ResourceConfig{
 		CRUD:   ops,
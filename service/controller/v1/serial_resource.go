@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"context"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/resource"
+)
+
+// serialResource runs the wrapped resource for one object at a time. Volumes
+// are reconciled by the controller and by the requeuer, which must not act on
+// a volume concurrently.
+type serialResource struct {
+	mutex    *sync.Mutex
+	resource resource.Interface
+}
+
+// wrapSerial wraps the given resources so that only one of them runs at a
+// time.
+func wrapSerial(resources []resource.Interface) []resource.Interface {
+	mutex := &sync.Mutex{}
+
+	var wrapped []resource.Interface
+	for _, r := range resources {
+		wrapped = append(wrapped, &serialResource{
+			mutex:    mutex,
+			resource: r,
+		})
+	}
+
+	return wrapped
+}
+
+func (r *serialResource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := r.resource.EnsureCreated(ctx, obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *serialResource) EnsureDeleted(ctx context.Context, obj interface{}) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := r.resource.EnsureDeleted(ctx, obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *serialResource) Name() string {
+	return r.resource.Name()
+}
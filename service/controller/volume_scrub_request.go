@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/giantswarm/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/operatorkit/controller"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	v1 "github.com/giantswarm/pv-cleaner-operator/service/controller/v1"
+)
+
+const (
+	// scrubRequestResyncPeriod is the resync period of scrub requests. The
+	// progress of a request follows the recycle state of its volume, which
+	// does not cause events for the request itself.
+	scrubRequestResyncPeriod = 30 * time.Second
+)
+
+type VolumeScrubRequestConfig struct {
+	K8sClient k8sclient.Interface
+	Logger    micrologger.Logger
+
+	// Handles returns true for the persistent volumes managed by the
+	// operator.
+	Handles     func(pv *apiv1.PersistentVolume) bool
+	ProjectName string
+}
+
+// VolumeScrubRequest scrubs available or released persistent volumes on
+// demand, as requested by VolumeScrubRequest custom resources.
+type VolumeScrubRequest struct {
+	*controller.Controller
+}
+
+func NewVolumeScrubRequest(config VolumeScrubRequestConfig) (*VolumeScrubRequest, error) {
+	var err error
+
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+
+	if config.Handles == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Handles must not be empty")
+	}
+	if config.ProjectName == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.ProjectName must not be empty")
+	}
+
+	var v1ResourceSet *controller.ResourceSet
+	{
+		c := v1.ScrubRequestResourceSetConfig{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+			Handles:   config.Handles,
+
+			ProjectName: config.ProjectName,
+		}
+
+		v1ResourceSet, err = v1.NewScrubRequestResourceSet(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var volumeScrubRequestController *controller.Controller
+	{
+		c := controller.Config{
+			CRD:       v1alpha1.NewVolumeScrubRequestCRD(),
+			K8sClient: config.K8sClient,
+			NewRuntimeObjectFunc: func() runtime.Object {
+				return new(v1alpha1.VolumeScrubRequest)
+			},
+			Logger: config.Logger,
+			ResourceSets: []*controller.ResourceSet{
+				v1ResourceSet,
+			},
+			ResyncPeriod: scrubRequestResyncPeriod,
+
+			Name: config.ProjectName + "-volume-scrub-request",
+		}
+
+		volumeScrubRequestController, err = controller.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	v := &VolumeScrubRequest{
+		Controller: volumeScrubRequestController,
+	}
+
+	return v, nil
+}
@@ -12,3 +12,21 @@ var invalidConfigError = &microerror.Error{
 func IsInvalidConfig(err error) bool {
 	return microerror.Cause(err) == invalidConfigError
 }
+
+var forbiddenError = &microerror.Error{
+	Kind: "forbiddenError",
+}
+
+// IsForbidden asserts forbiddenError.
+func IsForbidden(err error) bool {
+	return microerror.Cause(err) == forbiddenError
+}
+
+var namespaceNotFoundError = &microerror.Error{
+	Kind: "namespaceNotFoundError",
+}
+
+// IsNamespaceNotFound asserts namespaceNotFoundError.
+func IsNamespaceNotFound(err error) bool {
+	return microerror.Cause(err) == namespaceNotFoundError
+}
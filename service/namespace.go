@@ -0,0 +1,72 @@
+package service
+
+import (
+	"github.com/giantswarm/microerror"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// namespacePermission is an action the operator performs on cleanup objects
+// in the cleanup namespace.
+type namespacePermission struct {
+	group    string
+	resource string
+	verb     string
+}
+
+// namespacePermissions are the actions the operator needs to be allowed to
+// perform in the cleanup namespace.
+var namespacePermissions = []namespacePermission{
+	{group: "", resource: "persistentvolumeclaims", verb: "create"},
+	{group: "", resource: "persistentvolumeclaims", verb: "delete"},
+	{group: "", resource: "persistentvolumeclaims", verb: "get"},
+	{group: "", resource: "persistentvolumeclaims", verb: "list"},
+	{group: "", resource: "persistentvolumeclaims", verb: "watch"},
+	{group: "", resource: "pods", verb: "delete"},
+	{group: "", resource: "pods", verb: "deletecollection"},
+	{group: "", resource: "pods", verb: "list"},
+	{group: "batch", resource: "jobs", verb: "create"},
+	{group: "batch", resource: "jobs", verb: "delete"},
+	{group: "batch", resource: "jobs", verb: "get"},
+	{group: "batch", resource: "jobs", verb: "list"},
+	{group: "batch", resource: "jobs", verb: "update"},
+	{group: "batch", resource: "jobs", verb: "watch"},
+}
+
+// checkNamespace ensures that the cleanup namespace exists and that the
+// operator is allowed to manage cleanup claims, cleanup jobs and cleanup pods
+// in it.
+func checkNamespace(k8sClient kubernetes.Interface, namespace string) error {
+	_, err := k8sClient.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return microerror.Maskf(namespaceNotFoundError, "cleanup namespace %#q", namespace)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, p := range namespacePermissions {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:     p.group,
+					Namespace: namespace,
+					Resource:  p.resource,
+					Verb:      p.verb,
+				},
+			},
+		}
+
+		result, err := k8sClient.AuthorizationV1().SelfSubjectAccessReviews().Create(review)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if !result.Status.Allowed {
+			return microerror.Maskf(forbiddenError, "%s %s in cleanup namespace %#q", p.verb, p.resource, namespace)
+		}
+	}
+
+	return nil
+}
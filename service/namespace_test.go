@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_Service_checkNamespace(t *testing.T) {
+	testCases := []struct {
+		description  string
+		namespace    string
+		deniedVerb   string
+		errorMatcher func(error) bool
+	}{
+		{
+			description:  "existing namespace with all permissions",
+			namespace:    "giantswarm",
+			errorMatcher: nil,
+		},
+		{
+			description:  "missing namespace",
+			namespace:    "cleanup",
+			errorMatcher: IsNamespaceNotFound,
+		},
+		{
+			description:  "existing namespace with missing permission",
+			namespace:    "giantswarm",
+			deniedVerb:   "deletecollection",
+			errorMatcher: IsForbidden,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			k8sClient := fake.NewSimpleClientset(&apiv1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "giantswarm",
+				},
+			})
+			k8sClient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				review.Status.Allowed = review.Spec.ResourceAttributes.Verb != tc.deniedVerb
+				return true, review, nil
+			})
+
+			err := checkNamespace(k8sClient, tc.namespace)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("case %d expected %#v got %#v", i+1, "error", nil)
+			case !tc.errorMatcher(err):
+				t.Fatalf("case %d expected %#v got %#v", i+1, true, false)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+// Package requeuer watches cleanup jobs and cleanup claims and requeues the
+// persistent volume they were created for whenever they change, so that the
+// volume is reconciled as soon as its cleanup progresses instead of on the
+// next resync. Claims opting in to be restored to a released volume requeue
+// that volume as well.
+package requeuer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+const (
+	// previousClaimIndex indexes persistent volumes by the claim they were
+	// bound to before they got released.
+	previousClaimIndex = "previousClaim"
+)
+
+type Config struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+	// Reconciler reconciles the requeued persistent volumes, usually the
+	// persistent volume controller. Errors are expected to be handled by the
+	// reconciler, as operatorkit controllers do.
+	Reconciler reconcile.Reconciler
+
+	// Namespace is the namespace cleanup claims and cleanup jobs are created
+	// in.
+	Namespace string
+}
+
+type Requeuer struct {
+	k8sClient      kubernetes.Interface
+	logger         micrologger.Logger
+	queue          workqueue.Interface
+	reconciler     reconcile.Reconciler
+	restoreFactory informers.SharedInformerFactory
+	volumes        cache.Indexer
+
+	namespace string
+}
+
+func New(config Config) (*Requeuer, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+	if config.Reconciler == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Reconciler must not be empty")
+	}
+
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.Namespace must not be empty")
+	}
+
+	// Claims of workloads are watched in every namespace, since any of them
+	// may be recreated to restore its released volume. Volumes are looked up
+	// by the claim they were released by.
+	restoreFactory := informers.NewSharedInformerFactory(config.K8sClient, 0)
+
+	volumeInformer := restoreFactory.Core().V1().PersistentVolumes().Informer()
+	err := volumeInformer.AddIndexers(cache.Indexers{previousClaimIndex: indexPreviousClaim})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r := &Requeuer{
+		k8sClient:      config.K8sClient,
+		logger:         config.Logger,
+		queue:          workqueue.NewNamed("requeuer"),
+		reconciler:     config.Reconciler,
+		restoreFactory: restoreFactory,
+		volumes:        volumeInformer.GetIndexer(),
+
+		namespace: config.Namespace,
+	}
+
+	return r, nil
+}
+
+// Boot watches cleanup jobs and cleanup claims and reconciles the requeued
+// persistent volumes until the given context is done.
+func (r *Requeuer) Boot(ctx context.Context) {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		r.k8sClient,
+		0,
+		informers.WithNamespace(r.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = labels.SelectorFromSet(map[string]string{cleaner.CleanupLabel: cleaner.CleanupLabelValue}).String()
+		}),
+	)
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			r.handle(ctx, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			r.handle(ctx, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			r.handle(ctx, obj)
+		},
+	}
+
+	factory.Batch().V1().Jobs().Informer().AddEventHandler(handler)
+	factory.Core().V1().PersistentVolumeClaims().Informer().AddEventHandler(handler)
+
+	factory.Start(ctx.Done())
+
+	restoreHandler := cache.FilteringResourceEventHandler{
+		FilterFunc: isRestoreClaim,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				r.handleRestore(ctx, obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				r.handleRestore(ctx, newObj)
+			},
+		},
+	}
+
+	r.restoreFactory.Core().V1().PersistentVolumeClaims().Informer().AddEventHandler(restoreHandler)
+
+	r.restoreFactory.Start(ctx.Done())
+
+	go func() {
+		<-ctx.Done()
+		r.queue.ShutDown()
+	}()
+
+	go func() {
+		for r.reconcileNext(ctx) {
+		}
+	}()
+}
+
+func (r *Requeuer) handle(ctx context.Context, obj interface{}) {
+	err := r.Requeue(obj)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "error", "message", "failed requeueing persistent volume", "stack", microerror.JSON(err))
+	}
+}
+
+func (r *Requeuer) handleRestore(ctx context.Context, obj interface{}) {
+	err := r.RequeueRestore(obj)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "error", "message", "failed requeueing released persistent volume", "stack", microerror.JSON(err))
+	}
+}
+
+// Requeue queues the persistent volume the given cleanup object was created
+// for. Objects which do not belong to any volume are ignored. Volumes are
+// queued once, however often their cleanup objects change before they are
+// reconciled.
+func (r *Requeuer) Requeue(obj interface{}) error {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	name := m.GetAnnotations()[cleaner.VolumeAnnotation]
+	if name == "" {
+		return nil
+	}
+
+	r.queue.Add(name)
+
+	return nil
+}
+
+// RequeueRestore queues the released persistent volume the given pending
+// claim can be restored to, if the claim opts in using the restore
+// annotation. The volume records a claim with the same namespace and name in
+// its previous claim annotation. Volumes which are being cleaned already are
+// ignored.
+func (r *Requeuer) RequeueRestore(obj interface{}) error {
+	if !isRestoreClaim(obj) {
+		return nil
+	}
+	pvc := obj.(*apiv1.PersistentVolumeClaim)
+	if pvc.Status.Phase != apiv1.ClaimPending {
+		return nil
+	}
+
+	volumes, err := r.volumes.ByIndex(previousClaimIndex, fmt.Sprintf("%s/%s", pvc.Namespace, pvc.Name))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, obj := range volumes {
+		pv, ok := obj.(*apiv1.PersistentVolume)
+		if !ok || pv.Status.Phase != apiv1.VolumeReleased {
+			continue
+		}
+		if ref := pv.Spec.ClaimRef; ref != nil && ref.UID == pvc.UID {
+			continue
+		}
+		switch recycle.State(pv.Annotations[recycle.StateAnnotation]) {
+		case "", recycle.Recycled:
+		default:
+			continue
+		}
+
+		r.queue.Add(pv.Name)
+	}
+
+	return nil
+}
+
+// isRestoreClaim checks whether the given object is a claim opting in to be
+// restored to its released volume.
+func isRestoreClaim(obj interface{}) bool {
+	pvc, ok := obj.(*apiv1.PersistentVolumeClaim)
+	if !ok {
+		return false
+	}
+
+	return pvc.Annotations[recycle.RestoreAnnotation] == "true"
+}
+
+// indexPreviousClaim indexes the given persistent volume by the claim it was
+// bound to before it got released.
+func indexPreviousClaim(obj interface{}) ([]string, error) {
+	pv, ok := obj.(*apiv1.PersistentVolume)
+	if !ok {
+		return nil, nil
+	}
+
+	claim := pv.Annotations[recycle.PreviousClaimAnnotation]
+	if claim == "" {
+		return nil, nil
+	}
+
+	return []string{claim}, nil
+}
+
+// reconcileNext reconciles the next queued persistent volume. It returns false
+// once the queue is shut down.
+//
+// operatorkit does not expose the queue of its controller, so volumes are
+// reconciled from here, next to the worker of the controller. That is safe,
+// since the resources of the controller run for one volume at a time, see
+// serialResource, and operatorkit guards its finalizer patches with the
+// resource version of the volume, so that concurrent patches conflict instead
+// of overwriting each other. The controller handles reconciliation errors
+// itself and never returns them, volumes failing to be reconciled are retried
+// on its next resync.
+func (r *Requeuer) reconcileNext(ctx context.Context) bool {
+	key, shutdown := r.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.queue.Done(key)
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name: key.(string),
+		},
+	}
+
+	r.reconciler.Reconcile(req)
+
+	return true
+}
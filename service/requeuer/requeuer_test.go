@@ -0,0 +1,242 @@
+package requeuer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Requeuer_Requeue(t *testing.T) {
+	testCases := []struct {
+		description      string
+		jobs             []*batchv1.Job
+		expectedRequests []string
+	}{
+		{
+			description: "volume of changed job is reconciled",
+			jobs: []*batchv1.Job{
+				newJob("TestPersistentVolume"),
+			},
+			expectedRequests: []string{"TestPersistentVolume"},
+		},
+		{
+			description: "volume is reconciled once for many changes",
+			jobs: []*batchv1.Job{
+				newJob("TestPersistentVolume"),
+				newJob("TestPersistentVolume"),
+				newJob("OtherPersistentVolume"),
+			},
+			expectedRequests: []string{"TestPersistentVolume", "OtherPersistentVolume"},
+		},
+		{
+			description: "job without volume is ignored",
+			jobs: []*batchv1.Job{
+				newJob(""),
+			},
+			expectedRequests: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			reconciler := &reconciler{}
+
+			var err error
+			var newRequeuer *Requeuer
+			{
+				c := Config{
+					K8sClient:  fake.NewSimpleClientset(),
+					Logger:     microloggertest.New(),
+					Reconciler: reconciler,
+
+					Namespace: metav1.NamespaceSystem,
+				}
+				newRequeuer, err = New(c)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			for _, job := range tc.jobs {
+				err = newRequeuer.Requeue(job)
+				if err != nil {
+					t.Fatalf("case %d unexpected error returned requeueing volume: %s\n", i+1, err)
+				}
+			}
+
+			newRequeuer.queue.ShutDown()
+			for newRequeuer.reconcileNext(context.Background()) {
+			}
+
+			if !reflect.DeepEqual(reconciler.names(), tc.expectedRequests) {
+				t.Fatalf("case %d expected reconciled volumes %#v got %#v", i+1, tc.expectedRequests, reconciler.names())
+			}
+		})
+	}
+}
+
+func Test_Requeuer_RequeueRestore(t *testing.T) {
+	testCases := []struct {
+		description      string
+		recycleState     recycle.State
+		previousClaim    string
+		annotations      map[string]string
+		uid              types.UID
+		expectedRequests []string
+	}{
+		{
+			description:      "released volume is requeued on recreated claim opting in",
+			previousClaim:    "default/data",
+			recycleState:     recycle.Recycled,
+			annotations:      map[string]string{recycle.RestoreAnnotation: "true"},
+			uid:              "new",
+			expectedRequests: []string{"TestPersistentVolume"},
+		},
+		{
+			description:      "released volume is not requeued on recreated claim not opting in",
+			previousClaim:    "default/data",
+			recycleState:     recycle.Recycled,
+			annotations:      map[string]string{},
+			uid:              "new",
+			expectedRequests: nil,
+		},
+		{
+			description:      "queued volume is not requeued",
+			previousClaim:    "default/data",
+			recycleState:     recycle.Queued,
+			annotations:      map[string]string{recycle.RestoreAnnotation: "true"},
+			uid:              "new",
+			expectedRequests: nil,
+		},
+		{
+			description:      "released volume is not requeued on the claim it was released by",
+			previousClaim:    "default/data",
+			recycleState:     recycle.Recycled,
+			annotations:      map[string]string{recycle.RestoreAnnotation: "true"},
+			uid:              "old",
+			expectedRequests: nil,
+		},
+		{
+			description:      "released volume of other claim is not requeued",
+			previousClaim:    "default/other",
+			recycleState:     recycle.Recycled,
+			annotations:      map[string]string{recycle.RestoreAnnotation: "true"},
+			uid:              "new",
+			expectedRequests: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pv := &apiv1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "TestPersistentVolume",
+					Annotations: map[string]string{
+						recycle.PreviousClaimAnnotation: tc.previousClaim,
+						recycle.StateAnnotation:         string(tc.recycleState),
+					},
+				},
+				Spec: apiv1.PersistentVolumeSpec{
+					ClaimRef: &apiv1.ObjectReference{
+						Namespace: "default",
+						Name:      "data",
+						UID:       "old",
+					},
+				},
+				Status: apiv1.PersistentVolumeStatus{
+					Phase: apiv1.VolumeReleased,
+				},
+			}
+			pvc := &apiv1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "data",
+					Namespace:       "default",
+					Annotations:     tc.annotations,
+					ResourceVersion: "42",
+					UID:             tc.uid,
+				},
+				Status: apiv1.PersistentVolumeClaimStatus{
+					Phase: apiv1.ClaimPending,
+				},
+			}
+
+			reconciler := &reconciler{}
+
+			var err error
+			var newRequeuer *Requeuer
+			{
+				c := Config{
+					K8sClient:  fake.NewSimpleClientset(),
+					Logger:     microloggertest.New(),
+					Reconciler: reconciler,
+
+					Namespace: metav1.NamespaceSystem,
+				}
+				newRequeuer, err = New(c)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			err = newRequeuer.volumes.Add(pv)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned adding volume: %s\n", i+1, err)
+			}
+
+			err = newRequeuer.RequeueRestore(pvc)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned requeueing volume: %s\n", i+1, err)
+			}
+
+			newRequeuer.queue.ShutDown()
+			for newRequeuer.reconcileNext(context.Background()) {
+			}
+
+			if !reflect.DeepEqual(reconciler.names(), tc.expectedRequests) {
+				t.Fatalf("case %d expected reconciled volumes %#v got %#v", i+1, tc.expectedRequests, reconciler.names())
+			}
+		})
+	}
+}
+
+// reconciler records the reconciled requests.
+type reconciler struct {
+	requests []reconcile.Request
+}
+
+func (r *reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	r.requests = append(r.requests, req)
+	return reconcile.Result{}, nil
+}
+
+func (r *reconciler) names() []string {
+	var names []string
+	for _, req := range r.requests {
+		names = append(names, req.Name)
+	}
+	return names
+}
+
+func newJob(volume string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("pv-cleaner-job-pv-cleaner-claim-%s", volume),
+			Namespace: metav1.NamespaceSystem,
+			Annotations: map[string]string{
+				cleaner.VolumeAnnotation: volume,
+			},
+		},
+	}
+}
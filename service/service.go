@@ -4,18 +4,36 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/giantswarm/backoff"
 	"github.com/giantswarm/k8sclient"
 	"github.com/giantswarm/k8sclient/k8srestconfig"
 	"github.com/giantswarm/microendpoint/service/version"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/viper"
 
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 
 	"github.com/giantswarm/pv-cleaner-operator/flag"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/apis/pvcleaner/v1alpha1"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/service/admin"
+	"github.com/giantswarm/pv-cleaner-operator/service/cleanuppolicy"
+	"github.com/giantswarm/pv-cleaner-operator/service/collector"
 	"github.com/giantswarm/pv-cleaner-operator/service/controller"
+	"github.com/giantswarm/pv-cleaner-operator/service/controller/v1/resource/persistentvolume"
+	"github.com/giantswarm/pv-cleaner-operator/service/requeuer"
+	"github.com/giantswarm/pv-cleaner-operator/service/sweeper"
+	"github.com/giantswarm/pv-cleaner-operator/service/volume"
 )
 
 type Config struct {
@@ -32,10 +50,16 @@ type Config struct {
 }
 
 type Service struct {
+	Admin   *admin.Service
 	Version *version.Service
+	Volume  *volume.Service
 
-	bootOnce                   sync.Once
-	persistentVolumeController *controller.PersistentVolume
+	bootOnce                     sync.Once
+	cleanupPolicyLoader          *cleanuppolicy.Loader
+	persistentVolumeController   *controller.PersistentVolume
+	requeuer                     *requeuer.Requeuer
+	sweeper                      *sweeper.Sweeper
+	volumeScrubRequestController *controller.VolumeScrubRequest
 }
 
 func New(config Config) (*Service, error) {
@@ -77,7 +101,10 @@ func New(config Config) (*Service, error) {
 	var k8sClient *k8sclient.Clients
 	{
 		c := k8sclient.ClientsConfig{
-			Logger:     config.Logger,
+			Logger: config.Logger,
+			SchemeBuilder: k8sclient.SchemeBuilder{
+				v1alpha1.AddToScheme,
+			},
 			RestConfig: restConfig,
 		}
 		k8sClient, err = k8sclient.NewClients(c)
@@ -86,20 +113,213 @@ func New(config Config) (*Service, error) {
 		}
 	}
 
+	{
+		b := backoff.NewMaxRetries(3, 1*time.Second)
+		err = k8sClient.CRDClient().EnsureCreated(context.Background(), v1alpha1.NewVolumeScrubCRD(), b)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		b = backoff.NewMaxRetries(3, 1*time.Second)
+		err = k8sClient.CRDClient().EnsureCreated(context.Background(), v1alpha1.NewCleanupPolicyCRD(), b)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	cleanupNamespace := config.Viper.GetString(config.Flag.Service.Cleanup.Namespace)
+	{
+		err = checkNamespace(k8sClient.K8sClient(), cleanupNamespace)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var cleanupPolicy *policy.Policy
+	{
+		policyFile := config.Viper.GetString(config.Flag.Service.Policy.File)
+		if policyFile == "" {
+			cleanupPolicy = &policy.Policy{
+				Default: policy.Class{
+					BlockStrategy: cleaner.WipeHeaders,
+					Strategy:      cleaner.DeleteFiles,
+				},
+			}
+		} else {
+			cleanupPolicy, err = policy.Load(policyFile)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+	}
+
+	var cleanupPolicyLoader *cleanuppolicy.Loader
+	{
+		var strategies []string
+		for _, c := range cleaner.Builtin() {
+			strategies = append(strategies, c.Name())
+		}
+
+		var cleanupPolicyCache cache.Cache
+		cleanupPolicyCache, err = cache.New(k8sClient.RESTConfig(), cache.Options{Scheme: k8sClient.Scheme()})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		c := cleanuppolicy.Config{
+			Cache:  cleanupPolicyCache,
+			Logger: config.Logger,
+			Policy: cleanupPolicy,
+
+			Strategies: strategies,
+		}
+
+		cleanupPolicyLoader, err = cleanuppolicy.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var eventRecorder record.EventRecorder
+	{
+		eventBroadcaster := record.NewBroadcaster()
+		eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+			Interface: k8sClient.K8sClient().CoreV1().Events(""),
+		})
+		eventRecorder = eventBroadcaster.NewRecorder(scheme.Scheme, apiv1.EventSource{Component: config.ProjectName})
+	}
+
 	var persistentVolumeController *controller.PersistentVolume
 	{
 		c := controller.PersistentVolumeConfig{
+			Container: cleaner.ContainerConfig{
+				Args:             config.Viper.GetStringSlice(config.Flag.Service.Cleanup.Args),
+				Command:          config.Viper.GetStringSlice(config.Flag.Service.Cleanup.Command),
+				Image:            config.Viper.GetString(config.Flag.Service.Cleanup.Image),
+				ImagePullPolicy:  apiv1.PullPolicy(config.Viper.GetString(config.Flag.Service.Cleanup.ImagePullPolicy)),
+				ImagePullSecrets: config.Viper.GetStringSlice(config.Flag.Service.Cleanup.ImagePullSecrets),
+			},
+			EventRecorder: eventRecorder,
+			K8sClient:     k8sClient,
+			Logger:        config.Logger,
+			Policy:        cleanupPolicy,
+
+			Namespace:   cleanupNamespace,
+			ProjectName: config.ProjectName,
+		}
+
+		persistentVolumeController, err = controller.NewPersistentVolume(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+	}
+
+	// handles selects the volumes managed by the operator, just like the
+	// persistent volume controller does.
+	handles := func(pv *apiv1.PersistentVolume) bool {
+		return persistentvolume.Handles(cleanupPolicy, pv)
+	}
+
+	var volumeScrubRequestController *controller.VolumeScrubRequest
+	{
+		c := controller.VolumeScrubRequestConfig{
 			K8sClient: k8sClient,
 			Logger:    config.Logger,
 
+			Handles:     handles,
 			ProjectName: config.ProjectName,
 		}
 
-		persistentVolumeController, err = controller.NewPersistentVolume(c)
+		volumeScrubRequestController, err = controller.NewVolumeScrubRequest(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var volumeCollector *collector.Volume
+	{
+		c := collector.VolumeConfig{
+			K8sClient: k8sClient.K8sClient(),
+			Logger:    config.Logger,
+
+			Handles: handles,
+		}
+
+		volumeCollector, err = collector.NewVolume(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+	}
+
+	err = prometheus.Register(volumeCollector)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var cleanupRequeuer *requeuer.Requeuer
+	{
+		c := requeuer.Config{
+			K8sClient:  k8sClient.K8sClient(),
+			Logger:     config.Logger,
+			Reconciler: persistentVolumeController,
+
+			Namespace: cleanupNamespace,
+		}
+
+		cleanupRequeuer, err = requeuer.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var orphanSweeper *sweeper.Sweeper
+	{
+		c := sweeper.Config{
+			K8sClient: k8sClient.K8sClient(),
+			Logger:    config.Logger,
+
+			Namespace: cleanupNamespace,
+			Interval:  config.Viper.GetDuration(config.Flag.Service.Sweeper.Interval),
+			MinAge:    config.Viper.GetDuration(config.Flag.Service.Sweeper.MinAge),
+		}
+
+		orphanSweeper, err = sweeper.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var adminService *admin.Service
+	{
+		c := admin.Config{
+			K8sClient: k8sClient.K8sClient(),
+			Logger:    config.Logger,
+
+			Handles: handles,
+		}
+
+		adminService, err = admin.New(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
+	}
+
+	var volumeService *volume.Service
+	{
+		c := volume.Config{
+			K8sClient: k8sClient.K8sClient(),
+			Logger:    config.Logger,
+
+			Namespace: cleanupNamespace,
+			Handles:   handles,
+		}
 
+		volumeService, err = volume.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
 	}
 
 	var versionService *version.Service
@@ -119,10 +339,16 @@ func New(config Config) (*Service, error) {
 	}
 
 	newService := &Service{
+		Admin:   adminService,
 		Version: versionService,
+		Volume:  volumeService,
 
-		bootOnce:                   sync.Once{},
-		persistentVolumeController: persistentVolumeController,
+		bootOnce:                     sync.Once{},
+		cleanupPolicyLoader:          cleanupPolicyLoader,
+		persistentVolumeController:   persistentVolumeController,
+		requeuer:                     cleanupRequeuer,
+		sweeper:                      orphanSweeper,
+		volumeScrubRequestController: volumeScrubRequestController,
 	}
 
 	return newService, nil
@@ -130,6 +356,18 @@ func New(config Config) (*Service, error) {
 
 func (s *Service) Boot() {
 	s.bootOnce.Do(func() {
+		// Volumes are reconciled once their cleanup policies are loaded.
+		err := s.cleanupPolicyLoader.Boot(context.Background())
+		if err != nil {
+			panic(fmt.Sprintf("%#v\n", microerror.Mask(err)))
+		}
+		s.sweeper.Boot(context.Background())
+		go s.volumeScrubRequestController.Boot(context.Background())
+		go func() {
+			// Requeued volumes are reconciled once the controller runs.
+			<-s.persistentVolumeController.Booted()
+			s.requeuer.Boot(context.Background())
+		}()
 		s.persistentVolumeController.Boot(context.Background())
 	})
 }
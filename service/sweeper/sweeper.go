@@ -0,0 +1,215 @@
+// Package sweeper removes cleanup claims, cleanup jobs and cleanup pods which
+// are not used to clean any persistent volume anymore.
+package sweeper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+type Config struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// Namespace is the namespace cleanup claims, cleanup jobs and cleanup pods
+	// are created in.
+	Namespace string
+	// Interval is the time between two sweeps.
+	Interval time.Duration
+	// MinAge is the age an orphaned object must have before it is removed.
+	// It prevents objects from being removed which were just created and are
+	// not yet referenced by their volume.
+	MinAge time.Duration
+}
+
+type Sweeper struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+
+	namespace string
+	interval  time.Duration
+	minAge    time.Duration
+}
+
+func New(config Config) (*Sweeper, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.Namespace must not be empty")
+	}
+	if config.Interval <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "config.Interval must be greater than zero")
+	}
+	if config.MinAge < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "config.MinAge must not be negative")
+	}
+
+	s := &Sweeper{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		namespace: config.Namespace,
+		interval:  config.Interval,
+		minAge:    config.MinAge,
+	}
+
+	return s, nil
+}
+
+// Boot sweeps periodically until the given context is done.
+func (s *Sweeper) Boot(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := s.Sweep(ctx)
+				if err != nil {
+					s.logger.LogCtx(ctx, "level", "error", "message", "failed sweeping orphaned cleanup objects", "stack", microerror.JSON(err))
+				}
+			}
+		}
+	}()
+}
+
+// Sweep removes cleanup jobs, cleanup pods and cleanup claims which are older
+// than the minimum age and whose persistent volume does not exist anymore or
+// is not being cleaned.
+func (s *Sweeper) Sweep(ctx context.Context) error {
+	listOptions := metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{cleaner.CleanupLabel: cleaner.CleanupLabelValue}).String(),
+	}
+
+	// Jobs are removed first, so that they do not recreate their pods, and
+	// claims last, since they are kept by Kubernetes while pods use them.
+	{
+		jobs, err := s.k8sClient.BatchV1().Jobs(s.namespace).List(listOptions)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, job := range jobs.Items {
+			orphaned, err := s.isOrphaned(job.ObjectMeta)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			if !orphaned {
+				continue
+			}
+
+			err = s.k8sClient.BatchV1().Jobs(job.Namespace).Delete(job.Name, &metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return microerror.Mask(err)
+			}
+
+			s.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("removed orphaned cleanup job %#q", job.Name), "persistentvolume", job.Annotations[cleaner.VolumeAnnotation])
+		}
+	}
+
+	{
+		pods, err := s.k8sClient.CoreV1().Pods(s.namespace).List(listOptions)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, pod := range pods.Items {
+			orphaned, err := s.isOrphaned(pod.ObjectMeta)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			if !orphaned {
+				continue
+			}
+
+			err = s.k8sClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return microerror.Mask(err)
+			}
+
+			s.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("removed orphaned cleanup pod %#q", pod.Name), "persistentvolume", pod.Annotations[cleaner.VolumeAnnotation])
+		}
+	}
+
+	{
+		claims, err := s.k8sClient.CoreV1().PersistentVolumeClaims(s.namespace).List(listOptions)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, claim := range claims.Items {
+			orphaned, err := s.isOrphaned(claim.ObjectMeta)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			if !orphaned {
+				continue
+			}
+
+			err = s.k8sClient.CoreV1().PersistentVolumeClaims(claim.Namespace).Delete(claim.Name, &metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return microerror.Mask(err)
+			}
+
+			s.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("removed orphaned cleanup claim %#q", claim.Name), "persistentvolume", claim.Annotations[cleaner.VolumeAnnotation])
+		}
+	}
+
+	return nil
+}
+
+// isOrphaned checks whether the cleanup object with the given metadata is
+// older than the minimum age and not used anymore by the volume it was
+// created for. Objects are in use as long as their volume is in the Cleaning
+// or Teardown recycle state.
+func (s *Sweeper) isOrphaned(m metav1.ObjectMeta) (bool, error) {
+	if m.DeletionTimestamp != nil {
+		return false, nil
+	}
+	if time.Since(m.CreationTimestamp.Time) < s.minAge {
+		return false, nil
+	}
+
+	name := m.Annotations[cleaner.VolumeAnnotation]
+	if name == "" {
+		return true, nil
+	}
+
+	var pv *apiv1.PersistentVolume
+	{
+		var err error
+		pv, err = s.k8sClient.CoreV1().PersistentVolumes().Get(name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return true, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+	}
+
+	switch recycle.State(pv.Annotations[recycle.StateAnnotation]) {
+	case recycle.Cleaning, recycle.Teardown:
+		return false, nil
+	}
+
+	return true, nil
+}
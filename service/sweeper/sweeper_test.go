@@ -0,0 +1,132 @@
+package sweeper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Sweeper_Sweep(t *testing.T) {
+	testCases := []struct {
+		description     string
+		pv              *apiv1.PersistentVolume
+		age             time.Duration
+		expectedRemoved bool
+	}{
+		{
+			description:     "objects of a deleted volume are removed",
+			pv:              nil,
+			age:             2 * time.Hour,
+			expectedRemoved: true,
+		},
+		{
+			description:     "recent objects of a deleted volume are kept",
+			pv:              nil,
+			age:             time.Minute,
+			expectedRemoved: false,
+		},
+		{
+			description:     "objects of a recycled volume are removed",
+			pv:              newVolume(recycle.Recycled),
+			age:             2 * time.Hour,
+			expectedRemoved: true,
+		},
+		{
+			description:     "objects of a volume being cleaned are kept",
+			pv:              newVolume(recycle.Cleaning),
+			age:             2 * time.Hour,
+			expectedRemoved: false,
+		},
+		{
+			description:     "objects of a volume in teardown are kept",
+			pv:              newVolume(recycle.Teardown),
+			age:             2 * time.Hour,
+			expectedRemoved: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			meta := metav1.ObjectMeta{
+				Namespace:         metav1.NamespaceSystem,
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-tc.age)),
+				Annotations: map[string]string{
+					cleaner.VolumeAnnotation: "TestPersistentVolume",
+				},
+				Labels: map[string]string{
+					cleaner.CleanupLabel: cleaner.CleanupLabelValue,
+				},
+			}
+
+			pvc := &apiv1.PersistentVolumeClaim{ObjectMeta: *meta.DeepCopy()}
+			pvc.Name = "pv-cleaner-claim-TestPersistentVolume"
+			job := &batchv1.Job{ObjectMeta: *meta.DeepCopy()}
+			job.Name = "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume"
+			pod := &apiv1.Pod{ObjectMeta: *meta.DeepCopy()}
+			pod.Name = "pv-cleaner-job-pv-cleaner-claim-TestPersistentVolume-x7k2p"
+
+			objects := []runtime.Object{pvc, job, pod}
+			if tc.pv != nil {
+				objects = append(objects, tc.pv)
+			}
+			k8sClient := fake.NewSimpleClientset(objects...)
+
+			var err error
+			var newSweeper *Sweeper
+			{
+				c := Config{
+					K8sClient: k8sClient,
+					Logger:    microloggertest.New(),
+
+					Namespace: metav1.NamespaceSystem,
+					Interval:  time.Minute,
+					MinAge:    time.Hour,
+				}
+				newSweeper, err = New(c)
+				if err != nil {
+					t.Fatal("expected", nil, "got", err)
+				}
+			}
+
+			err = newSweeper.Sweep(context.TODO())
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned sweeping: %s\n", i+1, err)
+			}
+
+			_, err = k8sClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceSystem).Get(pvc.Name, metav1.GetOptions{})
+			if errors.IsNotFound(err) != tc.expectedRemoved {
+				t.Fatalf("case %d expected claim removed to be %t, got %#v", i+1, tc.expectedRemoved, err)
+			}
+			_, err = k8sClient.BatchV1().Jobs(metav1.NamespaceSystem).Get(job.Name, metav1.GetOptions{})
+			if errors.IsNotFound(err) != tc.expectedRemoved {
+				t.Fatalf("case %d expected job removed to be %t, got %#v", i+1, tc.expectedRemoved, err)
+			}
+			_, err = k8sClient.CoreV1().Pods(metav1.NamespaceSystem).Get(pod.Name, metav1.GetOptions{})
+			if errors.IsNotFound(err) != tc.expectedRemoved {
+				t.Fatalf("case %d expected pod removed to be %t, got %#v", i+1, tc.expectedRemoved, err)
+			}
+		})
+	}
+}
+
+func newVolume(state recycle.State) *apiv1.PersistentVolume {
+	return &apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "TestPersistentVolume",
+			Annotations: map[string]string{
+				recycle.StateAnnotation: string(state),
+			},
+		},
+	}
+}
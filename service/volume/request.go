@@ -0,0 +1,8 @@
+package volume
+
+// Request filters the volumes returned by the service action. Empty fields do
+// not filter.
+type Request struct {
+	RecycleState string
+	StorageClass string
+}
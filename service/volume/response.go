@@ -0,0 +1,23 @@
+package volume
+
+// Response describes a persistent volume managed by the operator.
+type Response struct {
+	Name               string     `json:"name"`
+	Phase              string     `json:"phase"`
+	RecycleState       string     `json:"recycle_state"`
+	StorageClass       string     `json:"storage_class"`
+	StateChangedAt     string     `json:"state_changed_at,omitempty"`
+	TimeInStateSeconds int64      `json:"time_in_state_seconds,omitempty"`
+	Held               bool       `json:"held,omitempty"`
+	CleanupAfter       string     `json:"cleanup_after,omitempty"`
+	CleanupInSeconds   int64      `json:"cleanup_in_seconds,omitempty"`
+	CleanupClaim       string     `json:"cleanup_claim,omitempty"`
+	CleanupJob         string     `json:"cleanup_job,omitempty"`
+	LastError          *LastError `json:"last_error,omitempty"`
+}
+
+// LastError is the last failure recorded on a persistent volume.
+type LastError struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,166 @@
+// Package volume provides the persistent volumes managed by the operator
+// together with their recycle state.
+package volume
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/policy"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+type Config struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// Namespace is the namespace cleanup claims and cleanup jobs are created
+	// in.
+	Namespace string
+	// Handles returns true for the persistent volumes managed by the
+	// operator.
+	Handles func(pv *apiv1.PersistentVolume) bool
+}
+
+type Service struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+
+	namespace string
+	handles   func(pv *apiv1.PersistentVolume) bool
+}
+
+func New(config Config) (*Service, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.Namespace must not be empty")
+	}
+	if config.Handles == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Handles must not be empty")
+	}
+
+	s := &Service{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		namespace: config.Namespace,
+		handles:   config.Handles,
+	}
+
+	return s, nil
+}
+
+// Search returns the persistent volumes managed by the operator which match
+// the given request, ordered by name.
+func (s *Service) Search(ctx context.Context, request Request) ([]Response, error) {
+	volumes, err := s.k8sClient.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	cleanupOptions := metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{cleaner.CleanupLabel: cleaner.CleanupLabelValue}).String(),
+	}
+
+	claims := map[string]string{}
+	{
+		list, err := s.k8sClient.CoreV1().PersistentVolumeClaims(s.namespace).List(cleanupOptions)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, c := range list.Items {
+			claims[c.Annotations[cleaner.VolumeAnnotation]] = c.Name
+		}
+	}
+
+	jobs := map[string]string{}
+	{
+		list, err := s.k8sClient.BatchV1().Jobs(s.namespace).List(cleanupOptions)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, j := range list.Items {
+			jobs[j.Annotations[cleaner.VolumeAnnotation]] = j.Name
+		}
+	}
+
+	responses := []Response{}
+	for _, pv := range volumes.Items {
+		if !s.handles(&pv) {
+			continue
+		}
+
+		// Volumes which were never recycled by the operator are considered
+		// recycled, just like the controller does.
+		state := pv.Annotations[recycle.StateAnnotation]
+		if state == "" {
+			state = string(recycle.Recycled)
+		}
+		storageClass := policy.StorageClass(&pv)
+
+		if request.RecycleState != "" && request.RecycleState != state {
+			continue
+		}
+		if request.StorageClass != "" && request.StorageClass != storageClass {
+			continue
+		}
+
+		response := Response{
+			Name:         pv.Name,
+			Phase:        string(pv.Status.Phase),
+			RecycleState: state,
+			StorageClass: storageClass,
+			CleanupClaim: claims[pv.Name],
+			CleanupJob:   jobs[pv.Name],
+		}
+
+		changedAt, err := time.Parse(time.RFC3339, pv.Annotations[recycle.StateChangedAtAnnotation])
+		if err == nil {
+			response.StateChangedAt = changedAt.Format(time.RFC3339)
+			response.TimeInStateSeconds = int64(time.Since(changedAt).Seconds())
+		}
+
+		// Released volumes within their grace period show the time left
+		// before they are cleaned.
+		response.Held = pv.Annotations[recycle.HoldAnnotation] == "true"
+		cleanupAfter, err := time.Parse(time.RFC3339, pv.Annotations[recycle.CleanupAfterAnnotation])
+		if err == nil {
+			response.CleanupAfter = cleanupAfter.Format(time.RFC3339)
+			if left := time.Until(cleanupAfter); left > 0 {
+				response.CleanupInSeconds = int64(left.Seconds())
+			}
+		}
+
+		if reason := pv.Annotations[recycle.FailureReasonAnnotation]; reason != "" {
+			response.LastError = &LastError{
+				Reason:  reason,
+				Message: pv.Annotations[recycle.FailureMessageAnnotation],
+			}
+		}
+
+		responses = append(responses, response)
+	}
+
+	sort.Slice(responses, func(i, j int) bool {
+		return responses[i].Name < responses[j].Name
+	})
+
+	return responses, nil
+}
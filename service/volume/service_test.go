@@ -0,0 +1,226 @@
+package volume
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/pv-cleaner-operator/pkg/cleaner"
+	"github.com/giantswarm/pv-cleaner-operator/pkg/recycle"
+)
+
+func Test_Volume_Search(t *testing.T) {
+	testCases := []struct {
+		description       string
+		request           Request
+		expectedResponses []Response
+	}{
+		{
+			description: "all managed volumes are returned",
+			request:     Request{},
+			expectedResponses: []Response{
+				{
+					Name:         "cleaning",
+					Phase:        "Bound",
+					RecycleState: "Cleaning",
+					StorageClass: "local-storage",
+					CleanupClaim: "pv-cleaner-claim-cleaning",
+					CleanupJob:   "pv-cleaner-job-pv-cleaner-claim-cleaning",
+				},
+				{
+					Name:         "failed",
+					Phase:        "Released",
+					RecycleState: "Failed",
+					StorageClass: "standard",
+					LastError: &LastError{
+						Reason:  "NodeNotFound",
+						Message: "no node matches the node affinity of node-local volume `failed`",
+					},
+				},
+				{
+					Name:         "held",
+					Phase:        "Released",
+					RecycleState: "Recycled",
+					StorageClass: "default",
+					Held:         true,
+				},
+				{
+					Name:         "recycled",
+					Phase:        "Available",
+					RecycleState: "Recycled",
+					StorageClass: "default",
+				},
+				{
+					Name:         "released",
+					Phase:        "Released",
+					RecycleState: "Recycled",
+					StorageClass: "default",
+					CleanupAfter: "2020-03-01T12:00:00Z",
+				},
+			},
+		},
+		{
+			description: "volumes are filtered by recycle state",
+			request:     Request{RecycleState: "Recycled"},
+			expectedResponses: []Response{
+				{
+					Name:         "held",
+					Phase:        "Released",
+					RecycleState: "Recycled",
+					StorageClass: "default",
+					Held:         true,
+				},
+				{
+					Name:         "recycled",
+					Phase:        "Available",
+					RecycleState: "Recycled",
+					StorageClass: "default",
+				},
+				{
+					Name:         "released",
+					Phase:        "Released",
+					RecycleState: "Recycled",
+					StorageClass: "default",
+					CleanupAfter: "2020-03-01T12:00:00Z",
+				},
+			},
+		},
+		{
+			description: "volumes are filtered by storage class",
+			request:     Request{StorageClass: "standard"},
+			expectedResponses: []Response{
+				{
+					Name:         "failed",
+					Phase:        "Released",
+					RecycleState: "Failed",
+					StorageClass: "standard",
+					LastError: &LastError{
+						Reason:  "NodeNotFound",
+						Message: "no node matches the node affinity of node-local volume `failed`",
+					},
+				},
+			},
+		},
+		{
+			description:       "volumes matching no filter are not returned",
+			request:           Request{RecycleState: "Cleaning", StorageClass: "standard"},
+			expectedResponses: []Response{},
+		},
+	}
+
+	managed := map[string]string{"persistentvolume.giantswarm.io/cleanup-on-release": "true"}
+	cleanup := map[string]string{cleaner.CleanupLabel: cleaner.CleanupLabelValue}
+
+	k8sClient := fake.NewSimpleClientset(
+		&apiv1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "cleaning",
+				Labels: managed,
+				Annotations: map[string]string{
+					recycle.StateAnnotation: "Cleaning",
+				},
+			},
+			Spec:   apiv1.PersistentVolumeSpec{StorageClassName: "local-storage"},
+			Status: apiv1.PersistentVolumeStatus{Phase: apiv1.VolumeBound},
+		},
+		&apiv1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "failed",
+				Labels: managed,
+				Annotations: map[string]string{
+					recycle.StateAnnotation:          "Failed",
+					recycle.FailureReasonAnnotation:  "NodeNotFound",
+					recycle.FailureMessageAnnotation: "no node matches the node affinity of node-local volume `failed`",
+				},
+			},
+			Spec:   apiv1.PersistentVolumeSpec{StorageClassName: "standard"},
+			Status: apiv1.PersistentVolumeStatus{Phase: apiv1.VolumeReleased},
+		},
+		&apiv1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "recycled",
+				Labels: managed,
+			},
+			Status: apiv1.PersistentVolumeStatus{Phase: apiv1.VolumeAvailable},
+		},
+		&apiv1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "held",
+				Labels: managed,
+				Annotations: map[string]string{
+					recycle.HoldAnnotation: "true",
+				},
+			},
+			Status: apiv1.PersistentVolumeStatus{Phase: apiv1.VolumeReleased},
+		},
+		&apiv1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "released",
+				Labels: managed,
+				Annotations: map[string]string{
+					recycle.CleanupAfterAnnotation: "2020-03-01T12:00:00Z",
+				},
+			},
+			Status: apiv1.PersistentVolumeStatus{Phase: apiv1.VolumeReleased},
+		},
+		&apiv1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "unmanaged",
+			},
+			Status: apiv1.PersistentVolumeStatus{Phase: apiv1.VolumeAvailable},
+		},
+		&apiv1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "pv-cleaner-claim-cleaning",
+				Namespace:   metav1.NamespaceSystem,
+				Labels:      cleanup,
+				Annotations: map[string]string{cleaner.VolumeAnnotation: "cleaning"},
+			},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "pv-cleaner-job-pv-cleaner-claim-cleaning",
+				Namespace:   metav1.NamespaceSystem,
+				Labels:      cleanup,
+				Annotations: map[string]string{cleaner.VolumeAnnotation: "cleaning"},
+			},
+		},
+	)
+
+	var err error
+	var newService *Service
+	{
+		c := Config{
+			K8sClient: k8sClient,
+			Logger:    microloggertest.New(),
+
+			Namespace: metav1.NamespaceSystem,
+			Handles: func(pv *apiv1.PersistentVolume) bool {
+				return labels.SelectorFromSet(managed).Matches(labels.Set(pv.Labels))
+			},
+		}
+		newService, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			responses, err := newService.Search(context.TODO(), tc.request)
+			if err != nil {
+				t.Fatalf("case %d unexpected error returned searching volumes: %s\n", i+1, err)
+			}
+			if !reflect.DeepEqual(responses, tc.expectedResponses) {
+				t.Fatalf("case %d expected %#v got %#v", i+1, tc.expectedResponses, responses)
+			}
+		})
+	}
+}